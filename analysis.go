@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+)
+
+// analysisHandler ranks the teams by any numeric season field or by a
+// composite of several of them
+func analysisHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	season, err := data.GetSeason(year)
+	if err != nil {
+		return err
+	}
+
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+	competition := request.FormValue("competition")
+	analysis, err := db.Analyze(competition)
+	if err != nil {
+		return err
+	}
+
+	fields := season.NumericFields()
+	fieldName := request.FormValue("field")
+	if season.Field(fieldName) == nil && len(fields) > 0 {
+		fieldName = fields[0].Name
+	}
+	expression := request.FormValue("composite")
+
+	message := ""
+	var rankings []data.Ranking
+	if expression != "" {
+		composite, err := data.ParseComposite(season, expression)
+		if err == nil {
+			rankings = analysis.RankComposite(composite)
+			expression = composite.String()
+		} else {
+			message = "The composite couldn't be understood. Use field names joined by + and - with spaces around them, such as 2*auto-gears + teleop-gears - fouls"
+		}
+	}
+	if rankings == nil {
+		rankings, err = analysis.RankField(fieldName)
+		if err != nil && err != data.ErrInvalidField {
+			return err
+		}
+	}
+
+	// narrow the rankings down to robots with certain pit scouted features
+	pitFilter := data.PitFilter{Field: request.FormValue("pit-field"), Value: request.FormValue("pit-value")}
+	if season.PitField(pitFilter.Field) != nil {
+		records, err := db.GetPitRecords(competition)
+		if err != nil {
+			return err
+		}
+		rankings = data.FilterRankings(season, rankings, records, pitFilter)
+	}
+
+	// power ratings only make sense within a single competition
+	ratings := map[int]data.PowerRating{}
+	if competition != "" {
+		computed, err := db.GetPowerRatings(competition)
+		if err != nil {
+			return err
+		}
+		for _, rating := range computed {
+			ratings[rating.Team] = rating
+		}
+	}
+
+	rows := ""
+	for i, ranking := range rankings {
+		stats := ranking.Stats
+		rows += fmt.Sprintf(`
+	<tr><td>%d</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%d</td>%s</tr>`,
+			i+1, genTeamLink(ranking.Team),
+			formatStat(stats, stats.Mean), formatStat(stats, stats.Median), formatStat(stats, stats.StdDev),
+			formatStat(stats, stats.Consistency), formatStat(stats, stats.Min), formatStat(stats, stats.Max),
+			stats.Count, genRatingCells(ratings, ranking.Team))
+	}
+
+	competitionOptions := []string{"", "All Competitions"}
+	for _, comp := range competitions {
+		competitionOptions = append(competitionOptions, comp.Key, comp.Name)
+	}
+	pitOptions := []string{"", "Any Robot"}
+	for _, field := range season.PitFields {
+		pitOptions = append(pitOptions, field.Name, field.Label)
+	}
+	fieldOptions := make([]string, 0, 2*len(fields))
+	fieldNames := ""
+	for _, field := range fields {
+		fieldOptions = append(fieldOptions, field.Name, field.Label)
+		fieldNames += fmt.Sprintf(`<code>%s</code> `, field.Name)
+	}
+
+	return writeAll(writer,
+		genPageStart("Analysis"),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genStylesheetElement("analysis"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Analysis</h1>
+<form class="analysis-form" action="analysis" method="get">
+	%s
+	<label>Rank by %s</label>
+	<label>or by composite <input name="composite" value="%s" placeholder="2*auto-gears + teleop-gears - fouls" type="text"></label>
+	<label>where %s is <input name="pit-value" value="%s" placeholder="swerve, >=100" type="text"></label>
+	<input type="submit" value="Rank">
+	<div class="analysis-fields">Fields: %s</div>
+</form>
+<span class="analysis-message">%s</span>
+<table class="team-table">
+	<tr><th>Rank</th><th>Team</th><th>Mean</th><th>Median</th><th>Std Dev</th><th>Consistency</th><th>Min</th><th>Max</th><th>Samples</th><th>OPR</th><th>DPR</th><th>CCWM</th></tr>%s
+</table>`,
+			genSelect("competition", competition, competitionOptions...),
+			genSelect("field", fieldName, fieldOptions...),
+			html.EscapeString(expression),
+			genSelect("pit-field", pitFilter.Field, pitOptions...), html.EscapeString(pitFilter.Value),
+			fieldNames, message, rows),
+		genPageEnd())
+}
+
+// genRatingCells creates the OPR, DPR and CCWM cells of a team, leaving them
+// blank if the team has no rating
+func genRatingCells(ratings map[int]data.PowerRating, team int) string {
+	rating, ok := ratings[team]
+	if !ok {
+		return `<td></td><td></td><td></td>`
+	}
+	return fmt.Sprintf(`<td>%.2f</td><td>%.2f</td><td>%.2f</td>`, rating.OPR, rating.DPR, rating.CCWM)
+}
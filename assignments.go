@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"scout/data"
+	"strconv"
+)
+
+// defaultMaxConsecutive is how many matches in a row a scout watches before a
+// break unless the scout lead chooses otherwise
+const defaultMaxConsecutive = 3
+
+// assignmentsHandler shows which scout watches which robot in every match of
+// a competition, and lets strategists assign the scouts who are available
+// to the matches that haven't been played yet
+func assignmentsHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	user := db.GetUser(request)
+	if user == nil && request.Method == "GET" {
+		http.Redirect(writer, request, "/login", http.StatusFound)
+		return nil
+	}
+	if user == nil {
+		return data.ErrAccessDenied
+	}
+	competition := request.FormValue("competition")
+
+	if request.Method == "POST" {
+		if !user.CanStrategize() {
+			return data.ErrAccessDenied
+		}
+		if request.ParseForm() != nil || competition == "" {
+			return data.ErrMalformedRequest
+		}
+		rules := data.ScheduleRules{
+			SkipOwnTeam:     request.PostFormValue("skip-own-team") == "1",
+			RotatePositions: request.PostFormValue("rotate") == "1",
+		}
+		var err error
+		if rules.MaxConsecutive, err = strconv.Atoi(request.PostFormValue("max-consecutive")); err != nil ||
+			rules.MaxConsecutive < 0 {
+			return data.ErrMalformedRequest
+		}
+		available := map[string]bool{}
+		for _, id := range request.PostForm["scout"] {
+			available[id] = true
+		}
+		scouts, err := db.GetScouts()
+		if err != nil {
+			return err
+		}
+		chosen := []data.Scout{}
+		for _, scout := range scouts {
+			if available[strconv.FormatInt(scout.Id, 10)] {
+				chosen = append(chosen, scout)
+			}
+		}
+		if _, err = db.ScheduleCompetition(user, competition, chosen, rules); err != nil {
+			return err
+		}
+		http.Redirect(writer, request, "/assignments?competition="+url.QueryEscape(competition), http.StatusFound)
+		return nil
+	} else if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+	competitionOptions := []string{}
+	for _, comp := range competitions {
+		competitionOptions = append(competitionOptions, comp.Key, comp.Name)
+	}
+	if competition == "" && len(competitions) > 0 {
+		competition = competitions[len(competitions)-1].Key
+	}
+
+	matches, err := db.GetMatches(competition)
+	if err != nil {
+		return err
+	}
+	assignments, err := db.GetAssignments(competition)
+	if err != nil {
+		return err
+	}
+	assigned := map[string][data.Positions]*data.Assignment{}
+	for i := range assignments {
+		assignment := &assignments[i]
+		key := assignment.Level.Abbreviation() + strconv.Itoa(assignment.Match)
+		positions := assigned[key]
+		positions[assignment.Position] = assignment
+		assigned[key] = positions
+	}
+
+	rows := ""
+	for _, match := range matches {
+		positions := assigned[match.Level.Abbreviation()+strconv.Itoa(match.Number)]
+		cells := ""
+		for position, team := range match.Teams() {
+			class, scout := "assignment-open", "Nobody"
+			if position < data.Positions && positions[position] != nil {
+				class, scout = "assignment", positions[position].ScoutName
+				if positions[position].ScoutId == user.Id {
+					class = "assignment-mine"
+				}
+			}
+			cells += fmt.Sprintf(`<td class="%s">%s<br>%s</td>`, class, genTeamLink(team), html.EscapeString(scout))
+		}
+		rows += fmt.Sprintf(`
+	<tr><td>%s%d</td>%s</tr>`, match.Level.Abbreviation(), match.Number, cells)
+	}
+
+	header := ""
+	for position := 0; position < data.Positions; position++ {
+		header += fmt.Sprintf(`<th>%s</th>`, data.PositionName(position))
+	}
+
+	scheduleForm := ""
+	if user.CanStrategize() && competition != "" {
+		if scheduleForm, err = genScheduleForm(db, competition); err != nil {
+			return err
+		}
+	}
+
+	return writeAll(writer,
+		genPageStart("Scout Assignments"),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genStylesheetElement("matches"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Scout Assignments</h1>
+<form class="match-filter" action="/assignments" method="get">
+	%s
+	<input type="submit" value="Show">
+</form>
+<table class="team-table">
+	<tr><th>Match</th>%s</tr>%s
+</table>%s`, genSelect("competition", competition, competitionOptions...), header, rows, scheduleForm),
+		genPageEnd())
+}
+
+// genScheduleForm lets strategists choose who is available and how scouts
+// may be assigned before assigning them to the matches left to play
+func genScheduleForm(db data.DB, competition string) (string, error) {
+	scouts, err := db.GetScouts()
+	if err != nil {
+		return "", err
+	}
+	choices, teamless := "", 0
+	for _, scout := range scouts {
+		team := ""
+		if scout.Team > 0 {
+			team = fmt.Sprintf(" (%d)", scout.Team)
+		} else {
+			teamless++
+		}
+		choices += fmt.Sprintf(`
+		<label><input name="scout" value="%d" type="checkbox" checked> %s%s</label>`,
+			scout.Id, html.EscapeString(scout.Name), team)
+	}
+	return fmt.Sprintf(`
+<form class="assignment-schedule" action="/assignments" method="post">
+	<input name="competition" value="%s" type="hidden">
+	<div class="assignment-scouts">%s
+	</div>
+	<label>Most matches in a row %s</label>
+	<label>%s Never watch their own team%s</label>
+	<label>%s Rotate positions</label>
+	<input type="submit" value="Assign matches left to play">
+</form>`, html.EscapeString(competition), choices, genNumberInput("max-consecutive", defaultMaxConsecutive, 0, 100),
+		genCheckbox("skip-own-team", true), genTeamlessNote(teamless), genCheckbox("rotate", true)), nil
+}
+
+// genTeamlessNote warns that scouts without a team can't be kept from
+// watching their own team, since it isn't known which one that is
+func genTeamlessNote(teamless int) string {
+	if teamless == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` (%d scouts have no team yet, so they may watch any robot; admins can set teams on the <a href="/users">Users</a> page)`,
+		teamless)
+}
+
+// genNextAssignment tells a scout which robot to watch next, with a link to
+// a submission form already filled in for it
+func genNextAssignment(db data.DB, user *data.User) (string, error) {
+	if user == nil {
+		return "", nil
+	}
+	assignment, err := db.GetNextAssignment(user)
+	if err == data.ErrAssignmentNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`
+<a class="next-assignment" href="/submit?competition=%s&amp;level=%s&amp;match=%d&amp;team=%d&amp;alliance=%s">
+	Your next match: %s%d, team %d at %s
+</a>`, url.QueryEscape(assignment.Competition), assignment.Level, assignment.Match, assignment.Team,
+		assignment.Alliance(), assignment.Level.Abbreviation(), assignment.Match, assignment.Team,
+		data.PositionName(assignment.Position)), nil
+}
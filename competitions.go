@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+)
+
+// competitionsHandler lists every competition of the year, and lets admins
+// import competitions from the configured source of official data
+func competitionsHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	user := db.GetUser(request)
+	admin := user != nil && user.Admin
+	message := ""
+	if request.Method == "POST" {
+		if !admin {
+			return data.ErrAccessDenied
+		}
+		if request.ParseForm() != nil {
+			return data.ErrMalformedRequest
+		}
+		key := request.PostFormValue("event-key")
+		name := request.PostFormValue("source")
+		var err error
+		if name != "" && sources[name] == nil {
+			return data.ErrMalformedRequest
+		}
+
+		switch request.PostFormValue("action") {
+		case "import":
+			if name == "" {
+				return data.ErrMalformedRequest
+			}
+			switch err = db.ImportCompetition(sources[name], key); err {
+			case nil:
+				message = "Imported " + key
+			case data.ErrCompetitionNotFound:
+				message = "No competition has the event key " + key
+			case data.ErrCompetitionYear:
+				message = fmt.Sprintf("%s isn't a %d competition", key, year)
+			case data.ErrSourceAccessDenied:
+				message = sourceLabels[name] + " refused our credentials"
+			default:
+				return err
+			}
+		case "source":
+			if err = db.SetCompetitionSource(key, name); err != nil {
+				return err
+			}
+			http.Redirect(writer, request, "/competitions", http.StatusFound)
+			return nil
+		default:
+			return data.ErrMalformedRequest
+		}
+	} else if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+
+	list := ""
+	for _, comp := range competitions {
+		teams := ""
+		for _, team := range comp.Teams {
+			teams += fmt.Sprintf(`<span class="competition-team">%s</span>`, genTeamLink(team))
+		}
+		list += fmt.Sprintf(`
+<div class="competition">
+	<div class="competition-header">
+		<span class="competition-name">%s</span>
+		<span class="competition-key">%s</span>
+	</div>
+	<div class="competition-details">%s, %s to %s</div>
+	<div class="competition-teams">%s</div>%s
+</div>`, html.EscapeString(comp.Name), html.EscapeString(comp.Key), html.EscapeString(comp.Location),
+			comp.Start.Date(), comp.End.Date(), teams, genSourceForm(admin, comp))
+	}
+	if len(competitions) == 0 {
+		list = `<span class="competition-empty">No competitions have been added for this year.</span>`
+	}
+
+	return writeAll(writer,
+		genPageStart(fmt.Sprintf("Competitions %d", year)),
+		genStylesheetElement("main"),
+		genStylesheetElement("competitions"),
+		genTopBar(request),
+		fmt.Sprintf(`<h1>Competitions %d</h1>`, year),
+		list,
+		genImportForm(year, admin, message),
+		genPageEnd())
+}
+
+// genSourceForm lets admins choose which source is authoritative for the
+// official schedule and results of a competition
+func genSourceForm(admin bool, comp data.Competition) string {
+	if !admin || len(sources) == 0 {
+		return ""
+	}
+	options := []string{"", "Entered by hand"}
+	for _, name := range sourceNames {
+		options = append(options, name, sourceLabels[name])
+	}
+	if comp.Source != "" && sources[comp.Source] == nil {
+		options = append(options, comp.Source, comp.Source+" (not configured)")
+	}
+	return fmt.Sprintf(`
+	<form class="competition-source" action="/competitions" method="post">
+		<input name="action" value="source" type="hidden">
+		<input name="event-key" value="%s" type="hidden">
+		<label>Official data from %s</label>
+		<input type="submit" value="Save">
+	</form>`, html.EscapeString(comp.Key), genSelect("source", comp.Source, options...))
+}
+
+// genImportForm creates the form admins import competitions with, offering
+// every event the sources know of that year
+func genImportForm(year int, admin bool, message string) string {
+	if !admin || len(sources) == 0 {
+		return ""
+	}
+	options := ""
+	known := map[string]bool{}
+	sourceOptions := []string{}
+	for _, name := range sourceNames {
+		sourceOptions = append(sourceOptions, name, sourceLabels[name])
+		events, err := sources[name].Events(year)
+		if err != nil {
+			continue
+		}
+		for _, event := range events {
+			if !known[event.Key] {
+				known[event.Key] = true
+				options += fmt.Sprintf(`
+		<option value="%s">%s</option>`, html.EscapeString(event.Key), html.EscapeString(event.Name))
+			}
+		}
+	}
+	return fmt.Sprintf(`
+<h2>Import a Competition</h2>
+<span class="competition-message">%s</span>
+<form class="competition-import" action="/competitions" method="post">
+	<input name="action" value="import" type="hidden">
+	<input name="event-key" list="events" placeholder="Event Key" type="text" required>
+	<datalist id="events">%s
+	</datalist>
+	%s
+	<input type="submit" value="Import">
+</form>`, html.EscapeString(message), options, genSelect("source", sourceNames[0], sourceOptions...))
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"scout/data"
+	"strings"
+	"time"
+)
+
+const (
+	// maxImportSize is the largest CSV file that can be uploaded for import
+	maxImportSize = 8 << 20
+	// importPreviewRows is how many rows of a CSV file are shown before it's
+	// imported
+	importPreviewRows = 10
+)
+
+// importHandler loads old data from CSV files, such as the responses of the
+// Google Forms used before the scouting system.  A file is uploaded, its
+// columns are mapped to fields and checked, and then it's imported as a
+// whole.  The file travels with the form between steps, so nothing is kept
+// on the server until it's imported.  Only admins may import data.
+func importHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	season, err := data.GetSeason(year)
+	if err != nil {
+		return err
+	}
+
+	user := db.GetUser(request)
+	if user == nil && request.Method == "GET" {
+		http.Redirect(writer, request, "/login", http.StatusFound)
+		return nil
+	}
+	if user == nil || !user.Admin {
+		return data.ErrAccessDenied
+	}
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+
+	if request.Method == "GET" {
+		return deliverImportUpload(writer, request, competitions, "")
+	} else if request.Method != "POST" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	request.Body = http.MaxBytesReader(writer, request.Body, maxImportSize)
+	if request.ParseMultipartForm(maxImportSize) != nil {
+		return data.ErrMalformedRequest
+	}
+	text := request.PostFormValue("csv")
+	if file, _, err := request.FormFile("file"); err == nil {
+		contents, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return data.ErrMalformedRequest
+		}
+		text = string(contents)
+	}
+
+	imp, err := data.ReadCSVImport(request.PostFormValue("kind"), strings.NewReader(text))
+	if importErr, ok := err.(data.ImportError); ok {
+		return deliverImportUpload(writer, request, competitions, importErr.Error())
+	} else if err != nil {
+		return data.ErrMalformedRequest
+	}
+	imp.Competition = request.PostFormValue("competition")
+	imp.Scout = user.Username
+	if _, mapped := request.PostForm["map-0"]; mapped {
+		for i := range imp.Mapping {
+			imp.Mapping[i] = request.PostFormValue(fmt.Sprintf("map-%d", i))
+		}
+	} else {
+		imp.GuessMapping(season)
+	}
+
+	var errs []data.ImportError
+	if request.PostFormValue("action") == "import" {
+		var imported int
+		if imported, errs, err = db.ApplyImport(imp); err != nil {
+			return err
+		}
+		if len(errs) == 0 {
+			return deliverImportUpload(writer, request, competitions, fmt.Sprintf("Imported %d %s", imported, imp.Kind))
+		}
+	} else if errs, err = db.CheckImport(imp); err != nil {
+		return err
+	}
+	return deliverImportPreview(writer, request, season, competitions, imp, text, errs)
+}
+
+// genCompetitionOptions lists every competition for a select, after a first
+// option that names none of them
+func genCompetitionOptions(competitions []data.Competition, none string) []string {
+	options := []string{"", none}
+	for _, comp := range competitions {
+		options = append(options, comp.Key, comp.Name)
+	}
+	return options
+}
+
+func deliverImportUpload(writer http.ResponseWriter, request *http.Request, competitions []data.Competition,
+	message string) error {
+	return writeAll(writer,
+		genPageStart("Import"),
+		genStylesheetElement("main"),
+		genStylesheetElement("import"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Import</h1>
+<span class="import-message">%s</span>
+<form class="import-upload" action="/import" method="post" enctype="multipart/form-data">
+	<label>File %s</label>
+	<label>Competition %s</label>
+	<input name="file" type="file" accept=".csv,text/csv" required>
+	<input type="submit" value="Preview">
+</form>`, html.EscapeString(message),
+			genSelect("kind", data.ImportSubmissions, data.ImportSubmissions, "Submissions", data.ImportMatches, "Match schedule"),
+			genSelect("competition", "", genCompetitionOptions(competitions, "Named in the file")...)),
+		genPageEnd())
+}
+
+// deliverImportPreview shows how the first rows of a CSV file would be read
+// and every problem that keeps it from being imported.  The columns can be
+// mapped differently and checked again until there are none.
+func deliverImportPreview(writer http.ResponseWriter, request *http.Request, season *data.Season,
+	competitions []data.Competition, imp *data.CSVImport, text string, errs []data.ImportError) error {
+	targets := []string{"", "Leave out"}
+	for _, target := range data.ImportTargets(imp.Kind, season) {
+		label := target
+		if field := season.Field(target); field != nil && imp.Kind == data.ImportSubmissions {
+			label = field.Label
+		}
+		targets = append(targets, target, label)
+	}
+
+	header := ""
+	for i, name := range imp.Header {
+		header += fmt.Sprintf(`<th>%s<br>%s</th>`, html.EscapeString(name),
+			genSelect(fmt.Sprintf("map-%d", i), imp.Mapping[i], targets...))
+	}
+	rows := ""
+	for i, row := range imp.Rows {
+		if i == importPreviewRows {
+			break
+		}
+		rows += fmt.Sprintf(`<tr><td class="import-line">%d</td>`, imp.Lines[i])
+		for _, value := range row {
+			rows += fmt.Sprintf(`<td>%s</td>`, html.EscapeString(value))
+		}
+		rows += "</tr>"
+	}
+
+	shown := len(imp.Rows)
+	if shown > importPreviewRows {
+		shown = importPreviewRows
+	}
+	problems := ""
+	for _, err := range errs {
+		problems += fmt.Sprintf(`<li>%s</li>`, html.EscapeString(err.Error()))
+	}
+	action := fmt.Sprintf(`<button name="action" value="import">Import %d rows</button>`, len(imp.Rows))
+	if len(errs) > 0 {
+		problems = fmt.Sprintf(`<ul class="import-errors">%s</ul>`, problems)
+		action = ""
+	}
+
+	return writeAll(writer,
+		genPageStart("Import"),
+		genStylesheetElement("main"),
+		genStylesheetElement("import"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Import %s</h1>
+<form action="/import" method="post" enctype="multipart/form-data">
+	<input name="kind" value="%s" type="hidden">
+	<textarea name="csv" hidden>%s</textarea>
+	<label class="import-competition">Competition %s</label>
+	<div class="import-table">
+		<table>
+			<thead><tr><th>Line</th>%s</tr></thead>
+			<tbody>%s</tbody>
+		</table>
+	</div>
+	<span class="import-message">Showing %d of %d rows</span>
+	%s
+	<button name="action" value="preview">Check again</button>
+	%s
+</form>`, html.EscapeString(imp.Kind), html.EscapeString(imp.Kind), html.EscapeString(text),
+			genSelect("competition", imp.Competition, genCompetitionOptions(competitions, "Named in the file")...),
+			header, rows, shown, len(imp.Rows), problems, action),
+		genPageEnd())
+}
+
+// mappingFlags collects every -map flag given to the import command
+type mappingFlags []string
+
+func (flags *mappingFlags) String() string {
+	return strings.Join(*flags, ",")
+}
+
+func (flags *mappingFlags) Set(value string) error {
+	*flags = append(*flags, value)
+	return nil
+}
+
+// importCommand imports a CSV file from the command line, for files too big
+// to upload or servers without an admin account yet.  Columns are mapped by
+// their headers unless mapped with -map "Header=target".  The mapping and
+// every problem are printed, and nothing is imported if there are any.
+func importCommand(args []string) int {
+	var mappings mappingFlags
+	commands := flag.NewFlagSet("import", flag.ContinueOnError)
+	year := commands.Int("year", time.Now().Year(), "the `year` whose database to import into")
+	kind := commands.String("kind", data.ImportSubmissions, "what the file holds: submissions or matches")
+	competition := commands.String("competition", "", "the event `key` of rows without a competition column")
+	scout := commands.String("scout", "", "the `username` credited with submissions without a scout column")
+	dryRun := commands.Bool("dry-run", false, "check the file without importing it")
+	commands.Var(&mappings, "map", "map a column to a target as `header=target`; may be repeated")
+	commands.Usage = func() {
+		fmt.Fprintln(commands.Output(), "usage: scout import [flags] file.csv")
+		commands.PrintDefaults()
+	}
+	if commands.Parse(args) != nil || commands.NArg() != 1 {
+		if commands.NArg() != 1 {
+			commands.Usage()
+		}
+		return exitUsageError
+	}
+
+	if err := data.LoadSeasons("seasons"); err != nil {
+		fmt.Fprintln(os.Stderr, "season error: "+err.Error())
+		return exitSeasonError
+	}
+	season, err := data.GetSeason(*year)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "season error: "+err.Error())
+		return exitSeasonError
+	}
+
+	file, err := os.Open(commands.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import error: "+err.Error())
+		return exitImportError
+	}
+	defer file.Close()
+	imp, err := data.ReadCSVImport(*kind, file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import error: "+err.Error())
+		return exitImportError
+	}
+	imp.Competition, imp.Scout = *competition, *scout
+	imp.GuessMapping(season)
+	for _, mapping := range mappings {
+		parts := strings.SplitN(mapping, "=", 2)
+		found := false
+		for i, header := range imp.Header {
+			if len(parts) == 2 && strings.TrimSpace(header) == strings.TrimSpace(parts[0]) {
+				imp.Mapping[i], found = strings.TrimSpace(parts[1]), true
+			}
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "import error: no column matches -map %q\n", mapping)
+			return exitUsageError
+		}
+	}
+
+	for i, header := range imp.Header {
+		target := imp.Mapping[i]
+		if target == "" {
+			target = "(left out)"
+		}
+		fmt.Printf("%q -> %s\n", header, target)
+	}
+
+	// the year may never have been served, so its tables may not exist yet
+	pending, err := data.Migrate(*year, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migration error: "+err.Error())
+		return exitMigrateError
+	}
+	if *dryRun && len(pending) > 0 {
+		fmt.Fprintf(os.Stderr, "migration error: the %d database needs %d migrations; run scout migrate -year %d first\n",
+			*year, len(pending), *year)
+		return exitMigrateError
+	}
+
+	db, err := data.ConnectToDatabase(*year)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import error: "+err.Error())
+		return exitImportError
+	}
+	defer db.Close()
+
+	var (
+		errs     []data.ImportError
+		imported int
+	)
+	if *dryRun {
+		errs, err = db.CheckImport(imp)
+	} else {
+		imported, errs, err = db.ApplyImport(imp)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import error: "+err.Error())
+		return exitImportError
+	}
+	for _, importErr := range errs {
+		fmt.Fprintln(os.Stderr, importErr.Error())
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "nothing was imported because of %d problems\n", len(errs))
+		return exitImportError
+	}
+	if *dryRun {
+		fmt.Printf("%d rows are ready to import\n", len(imp.Rows))
+	} else {
+		fmt.Printf("imported %d %s\n", imported, imp.Kind)
+	}
+	return exitSuccess
+}
@@ -0,0 +1,252 @@
+package data
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Analysis holds the aggregate performance of every scouted team at a
+// competition, or across every competition of the year.  Analyses are
+// shared between requests, so they must not be modified.
+type Analysis struct {
+	Season *Season
+	Teams  []TeamSummary
+
+	// submissions maps each team to the submissions made about it
+	submissions map[int][]Submission
+}
+
+// Ranking is the performance of a single team in a single field or
+// composite.
+type Ranking struct {
+	Team  int
+	Stats FieldStats
+}
+
+// Term is a single weighted field of a composite
+type Term struct {
+	Weight float64
+	Field  string
+}
+
+// Composite is a user-defined score that combines several fields, such as
+// 2*auto-gears + teleop-gears - fouls
+type Composite struct {
+	Terms []Term
+}
+
+type analysisKey struct {
+	year        int
+	competition string
+}
+
+var (
+	analysisCache     = map[analysisKey]*Analysis{}
+	analysisCacheLock sync.Mutex
+
+	// analysisGenerations counts the invalidations of each year, so that an
+	// analysis computed while a submission was being inserted isn't cached
+	analysisGenerations = map[int]int{}
+)
+
+// Analyze computes the statistics of every team for the given competition,
+// or for every competition if it's empty.  Results are cached until a
+// submission of the same year is inserted, edited or deleted.  Only
+// competitions that exist are cached, since the competition comes from the
+// request and anything else would let the cache grow without bound.
+func (db DB) Analyze(competition string) (*Analysis, error) {
+	key := analysisKey{year: db.year, competition: competition}
+	analysisCacheLock.Lock()
+	analysis, ok := analysisCache[key]
+	generation := analysisGenerations[db.year]
+	analysisCacheLock.Unlock()
+	if ok {
+		return analysis, nil
+	}
+
+	season, err := GetSeason(db.year)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := db.GetSubmissions(SubmissionFilter{Competition: competition})
+	if err != nil {
+		return nil, err
+	}
+
+	analysis = &Analysis{
+		Season:      season,
+		Teams:       SummarizeTeams(season, subs),
+		submissions: map[int][]Submission{},
+	}
+	for _, sub := range subs {
+		analysis.submissions[sub.Team] = append(analysis.submissions[sub.Team], sub)
+	}
+
+	if competition != "" {
+		if _, err = db.GetCompetition(competition); err == ErrCompetitionNotFound {
+			return analysis, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	analysisCacheLock.Lock()
+	if analysisGenerations[db.year] == generation {
+		analysisCache[key] = analysis
+	}
+	analysisCacheLock.Unlock()
+	return analysis, nil
+}
+
+// invalidateAnalyses throws away every cached analysis of the year
+func invalidateAnalyses(year int) {
+	analysisCacheLock.Lock()
+	defer analysisCacheLock.Unlock()
+	analysisGenerations[year]++
+	for key := range analysisCache {
+		if key.year == year {
+			delete(analysisCache, key)
+		}
+	}
+}
+
+// Team finds the summary of a single team.  Returns false if the team wasn't
+// scouted.
+func (analysis *Analysis) Team(team int) (TeamSummary, bool) {
+	for _, summary := range analysis.Teams {
+		if summary.Number == team {
+			return summary, true
+		}
+	}
+	return TeamSummary{}, false
+}
+
+// RankField orders the teams by their average in a numeric field, best
+// first.  Returns ErrInvalidField if the season has no such numeric field.
+func (analysis *Analysis) RankField(name string) ([]Ranking, error) {
+	field := analysis.Season.Field(name)
+	if field == nil || !field.Numeric() {
+		return nil, ErrInvalidField
+	}
+
+	rankings := make([]Ranking, 0, len(analysis.Teams))
+	for _, summary := range analysis.Teams {
+		rankings = append(rankings, Ranking{Team: summary.Number, Stats: summary.Stats[name]})
+	}
+	sortRankings(rankings)
+	return rankings, nil
+}
+
+// RankComposite scores every submission with the composite and orders the
+// teams by their average score, best first.
+func (analysis *Analysis) RankComposite(composite Composite) []Ranking {
+	field := Field{Name: "composite", Label: composite.String(), Kind: FieldCounter}
+
+	rankings := make([]Ranking, 0, len(analysis.Teams))
+	for _, summary := range analysis.Teams {
+		subs := analysis.submissions[summary.Number]
+		scores := make([]float64, 0, len(subs))
+		for _, sub := range subs {
+			if score, ok := composite.Score(analysis.Season, sub); ok {
+				scores = append(scores, score)
+			}
+		}
+		rankings = append(rankings, Ranking{Team: summary.Number, Stats: summarize(field, scores)})
+	}
+	sortRankings(rankings)
+	return rankings
+}
+
+func sortRankings(rankings []Ranking) {
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return rankings[i].Stats.Mean > rankings[j].Stats.Mean
+	})
+}
+
+// ParseComposite reads a composite from an expression such as
+// "2*auto-gears + teleop-gears - fouls".  Since field names may contain
+// dashes, the + and - between terms must be surrounded by spaces.  Returns
+// ErrInvalidComposite if the expression can't be understood, has a weight
+// that isn't a finite number, or names a field the season doesn't have.
+func ParseComposite(season *Season, expression string) (Composite, error) {
+	expression = strings.NewReplacer("*", " * ", "×", " * ").Replace(expression)
+	tokens := strings.Fields(expression)
+
+	composite := Composite{}
+	sign := 1.0
+	operator := false // whether an operator is still waiting for its term
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "+":
+			operator = true
+			continue
+		case "-":
+			sign, operator = -sign, true
+			continue
+		}
+
+		term := Term{Weight: sign}
+		if weight, err := strconv.ParseFloat(tokens[i], 64); err == nil || errors.Is(err, strconv.ErrRange) {
+			// weights out of range would turn every score into Inf or NaN
+			if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) ||
+				i+2 >= len(tokens) || tokens[i+1] != "*" {
+				return Composite{}, ErrInvalidComposite
+			}
+			term.Weight *= weight
+			i += 2
+		}
+		field := season.Field(tokens[i])
+		if field == nil || !field.Numeric() {
+			return Composite{}, ErrInvalidComposite
+		}
+		term.Field = field.Name
+		composite.Terms = append(composite.Terms, term)
+		sign, operator = 1, false
+	}
+
+	if len(composite.Terms) == 0 || operator {
+		return Composite{}, ErrInvalidComposite
+	}
+	return composite, nil
+}
+
+// String formats the composite the same way ParseComposite reads it
+func (composite Composite) String() string {
+	s := ""
+	for i, term := range composite.Terms {
+		weight := term.Weight
+		switch {
+		case weight < 0:
+			s += " - "
+			weight = -weight
+		case i > 0:
+			s += " + "
+		}
+		if weight != 1 {
+			s += strconv.FormatFloat(weight, 'g', -1, 64) + "*"
+		}
+		s += term.Field
+	}
+	return strings.TrimSpace(s)
+}
+
+// Score computes the composite for a single submission.  Returns false if the
+// submission is missing any of the fields.
+func (composite Composite) Score(season *Season, sub Submission) (float64, bool) {
+	score := 0.0
+	for _, term := range composite.Terms {
+		field := season.Field(term.Field)
+		if field == nil {
+			return 0, false
+		}
+		n, ok := field.Number(sub.Values[term.Field])
+		if !ok {
+			return 0, false
+		}
+		score += term.Weight * n
+	}
+	return score, true
+}
@@ -0,0 +1,98 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseComposite(t *testing.T) {
+	season, err := GetSeason(testYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expression string
+		terms      []Term
+	}{
+		{"scale", []Term{{1, "scale"}}},
+		{"2*scale + switch - fouls", []Term{{2, "scale"}, {1, "switch"}, {-1, "fouls"}}},
+		{"1.5 × auto-scale - - vault", []Term{{1.5, "auto-scale"}, {1, "vault"}}},
+		{"- 3 * fouls", []Term{{-3, "fouls"}}},
+		{"", nil},
+		{"scale -", nil},
+		{"scale - ", nil},
+		{"scale +", nil},
+		{"scale - -", nil},
+		{"-", nil},
+		{"2 *", nil},
+		{"2 * comments", nil},
+		{"scale + climbing", nil},
+		{"NaN * scale", nil},
+		{"Inf * scale", nil},
+		{"- -infinity * scale", nil},
+		{"1e309 * scale", nil},
+		{"1e-400 * scale", []Term{{0, "scale"}}}, // too small to tell from 0, but finite
+		{"1e308 * scale", []Term{{1e308, "scale"}}},
+	}
+	for _, test := range tests {
+		composite, err := ParseComposite(season, test.expression)
+		if test.terms == nil {
+			if err != ErrInvalidComposite {
+				t.Errorf("ParseComposite(%q) = %+v, %v, want ErrInvalidComposite", test.expression, composite, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(composite.Terms, test.terms) {
+			t.Errorf("ParseComposite(%q) = %+v, %v, want %+v", test.expression, composite, err, test.terms)
+			continue
+		}
+		// the composite reads back as itself
+		if again, err := ParseComposite(season, composite.String()); err != nil || !reflect.DeepEqual(again, composite) {
+			t.Errorf("ParseComposite(%q) = %+v, %v, want %+v", composite.String(), again, err, composite)
+		}
+	}
+}
+
+// cachedAnalysis reports whether the analysis of a competition is cached
+func cachedAnalysis(db DB, competition string) bool {
+	analysisCacheLock.Lock()
+	defer analysisCacheLock.Unlock()
+	_, ok := analysisCache[analysisKey{year: db.year, competition: competition}]
+	return ok
+}
+
+func TestAnalyzeCache(t *testing.T) {
+	db := newTestDB(t)
+	t.Cleanup(func() { invalidateAnalyses(db.year) })
+	if err := db.InsertCompetition(Competition{Key: "2018casj", Name: "Silicon Valley Regional"}); err != nil {
+		t.Fatal(err)
+	}
+	sub := testSubmission()
+	sub.ClientId = ""
+	if err := db.InsertSubmission(&sub); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, competition := range []string{"2018casj", ""} {
+		analysis, err := db.Analyze(competition)
+		if err != nil || len(analysis.Teams) != 1 || analysis.Teams[0].Number != sub.Team {
+			t.Errorf("Analyze(%q) = %+v, %v", competition, analysis, err)
+		}
+		if !cachedAnalysis(db, competition) {
+			t.Errorf("the analysis of %q wasn't cached", competition)
+		}
+	}
+
+	analysis, err := db.Analyze("2018nope")
+	if err != nil || len(analysis.Teams) != 0 {
+		t.Errorf("Analyze of an unknown competition = %+v, %v", analysis, err)
+	}
+	if cachedAnalysis(db, "2018nope") {
+		t.Error("the analysis of an unknown competition was cached")
+	}
+
+	invalidateAnalyses(db.year)
+	if cachedAnalysis(db, "2018casj") {
+		t.Error("the analysis outlived an invalidation")
+	}
+}
@@ -0,0 +1,288 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Positions is how many robots play in a match, and so how many scouts are
+// needed to watch all of them.  Positions 0 through 2 are Red 1 through Red 3
+// and positions 3 through 5 are Blue 1 through Blue 3.
+const Positions = 6
+
+// PositionName names a position the way drivers stations are named
+func PositionName(position int) string {
+	if position < 3 {
+		return fmt.Sprintf("Red %d", position+1)
+	}
+	return fmt.Sprintf("Blue %d", position-2)
+}
+
+// Scout is a user who may be assigned to watch robots
+type Scout struct {
+	Id   int64
+	Name string
+	Team int // the team the scout belongs to, or 0 if unknown
+}
+
+// Assignment is a scout assigned to watch the robot at one position of a
+// match
+type Assignment struct {
+	Competition string
+	Level       MatchLevel
+	Match       int
+	Position    int
+	Team        int
+	ScoutId     int64
+	ScoutName   string
+}
+
+// Alliance returns the alliance the watched robot plays on
+func (assignment Assignment) Alliance() Alliance {
+	if assignment.Position < 3 {
+		return Red
+	}
+	return Blue
+}
+
+// ScheduleRules limits how scouts may be assigned
+type ScheduleRules struct {
+	// MaxConsecutive is how many matches in a row a scout may be assigned
+	// before getting a break; 0 means there's no limit
+	MaxConsecutive int
+	// SkipOwnTeam keeps scouts from watching the robot of their own team
+	SkipOwnTeam bool
+	// RotatePositions spreads each scout across every position instead of
+	// only balancing how many matches they watch
+	RotatePositions bool
+}
+
+// ScheduleScouts assigns the scouts to watch every robot of the matches, in
+// the order they're given.  Assignments are fair: whoever has watched the
+// fewest robots so far is picked first, with ties going to whoever has the
+// shortest streak and then, if positions are rotated, to whoever has watched
+// the position least.  A position is left without a scout if the rules leave
+// nobody to watch it.
+func ScheduleScouts(matches []Match, scouts []Scout, rules ScheduleRules) []Assignment {
+	var (
+		load      = make([]int, len(scouts))
+		streak    = make([]int, len(scouts))
+		positions = make([][Positions]int, len(scouts))
+	)
+	assignments := []Assignment{}
+	for _, match := range matches {
+		teams := match.Teams()
+		if len(teams) != Positions {
+			continue
+		}
+		busy := make([]bool, len(scouts))
+		for position, team := range teams {
+			best := -1
+			for i, scout := range scouts {
+				if busy[i] || (rules.MaxConsecutive > 0 && streak[i] >= rules.MaxConsecutive) ||
+					(rules.SkipOwnTeam && scout.Team != 0 && scout.Team == team) {
+					continue
+				}
+				if best < 0 || fairer(i, best, position, load, streak, positions, rules.RotatePositions) {
+					best = i
+				}
+			}
+			if best < 0 {
+				continue
+			}
+			busy[best] = true
+			load[best]++
+			positions[best][position]++
+			assignments = append(assignments, Assignment{
+				Competition: match.Competition,
+				Level:       match.Level,
+				Match:       match.Number,
+				Position:    position,
+				Team:        team,
+				ScoutId:     scouts[best].Id,
+				ScoutName:   scouts[best].Name,
+			})
+		}
+		for i := range scouts {
+			if busy[i] {
+				streak[i]++
+			} else {
+				streak[i] = 0
+			}
+		}
+	}
+	return assignments
+}
+
+// fairer reports whether scout a should be assigned to a position before
+// scout b
+func fairer(a, b, position int, load, streak []int, positions [][Positions]int, rotate bool) bool {
+	if load[a] != load[b] {
+		return load[a] < load[b]
+	}
+	if streak[a] != streak[b] {
+		return streak[a] < streak[b]
+	}
+	if rotate && positions[a][position] != positions[b][position] {
+		return positions[a][position] < positions[b][position]
+	}
+	return false // keep the order the scouts were given in
+}
+
+// GetScouts retrieves every user who may be assigned to scout, ordered by
+// name
+func (db DB) GetScouts() ([]Scout, error) {
+	rows, err := db.db.Query(`SELECT id, realname, team FROM users ORDER BY realname`)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.GetScouts: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	scouts := []Scout{}
+	for rows.Next() {
+		var scout Scout
+		if err = rows.Scan(&scout.Id, &scout.Name, &scout.Team); err != nil {
+			return nil, err
+		}
+		scouts = append(scouts, scout)
+	}
+	return scouts, rows.Err()
+}
+
+// ScheduleCompetition assigns the scouts to every match of a competition that
+// hasn't been played yet, replacing whatever was assigned to those matches
+// before.  Assignments for matches already played are kept as they were.
+// Only strategists may assign scouts.
+func (db DB) ScheduleCompetition(user *User, competition string, scouts []Scout, rules ScheduleRules) ([]Assignment, error) {
+	if !user.CanStrategize() {
+		return nil, ErrAccessDenied
+	}
+	matches, err := db.GetMatches(competition)
+	if err != nil {
+		return nil, err
+	}
+	unplayed := []Match{}
+	for _, match := range matches {
+		if !match.Scored {
+			unplayed = append(unplayed, match)
+		}
+	}
+	assignments := ScheduleScouts(unplayed, scouts, rules)
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	for _, match := range unplayed {
+		_, err = tx.Exec(`DELETE FROM scout_assignments WHERE competition=? AND level=? AND match_number=?`,
+			competition, match.Level.String(), match.Number)
+		if err != nil {
+			break
+		}
+	}
+	for _, assignment := range assignments {
+		if err != nil {
+			break
+		}
+		_, err = tx.Exec(`INSERT INTO scout_assignments
+ (competition, level, match_number, position, team, scout) VALUES (?, ?, ?, ?, ?, ?)`,
+			competition, assignment.Level.String(), assignment.Match, assignment.Position, assignment.Team,
+			assignment.ScoutId)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.ScheduleCompetition: "+err.Error())
+		return nil, ErrDatabaseUpdate
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, ErrDatabaseUpdate
+	}
+	return assignments, nil
+}
+
+// GetAssignments retrieves every assignment of a competition, ordered by
+// match and position
+func (db DB) GetAssignments(competition string) ([]Assignment, error) {
+	return db.queryAssignments(`WHERE a.competition=?`, competition)
+}
+
+// GetNextAssignment finds the first match the user is assigned to that hasn't
+// been played yet and that they haven't already submitted.  Returns
+// ErrAssignmentNotFound if the user has nothing left to scout.
+func (db DB) GetNextAssignment(user *User) (*Assignment, error) {
+	if user == nil {
+		return nil, ErrAccessDenied
+	}
+	assignments, err := db.queryAssignments(`
+ JOIN matches m ON m.competition=a.competition AND m.level=a.level AND m.match_number=a.match_number
+ WHERE a.scout=? AND m.red_score IS NULL AND NOT EXISTS (SELECT 1 FROM submissions s
+  WHERE s.competition=a.competition AND s.level=a.level AND s.match_number=a.match_number AND s.team=a.team
+  AND s.scout=a.scout)`, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if len(assignments) == 0 {
+		return nil, ErrAssignmentNotFound
+	}
+	return &assignments[0], nil
+}
+
+// queryAssignments retrieves the assignments matched by the rest of a query
+// that refers to the table of assignments as a
+func (db DB) queryAssignments(where string, args ...interface{}) ([]Assignment, error) {
+	rows, err := db.db.Query(`SELECT a.competition, a.level, a.match_number, a.position, a.team, a.scout, u.realname
+ FROM scout_assignments a JOIN users u ON u.id=a.scout `+where, args...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.queryAssignments: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []Assignment{}
+	for rows.Next() {
+		var (
+			assignment Assignment
+			level      string
+		)
+		err = rows.Scan(&assignment.Competition, &level, &assignment.Match, &assignment.Position,
+			&assignment.Team, &assignment.ScoutId, &assignment.ScoutName)
+		if err != nil {
+			return nil, err
+		}
+		if assignment.Level, err = ParseMatchLevel(level); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, assignment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// competitions are in the order they start
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return nil, err
+	}
+	order := map[string]int{}
+	for i, comp := range competitions {
+		order[comp.Key] = i
+	}
+	sort.SliceStable(assignments, func(i, j int) bool {
+		a, b := assignments[i], assignments[j]
+		if a.Competition != b.Competition {
+			return order[a.Competition] < order[b.Competition]
+		}
+		if a.Level != b.Level {
+			return a.Level < b.Level
+		}
+		if a.Match != b.Match {
+			return a.Match < b.Match
+		}
+		return a.Position < b.Position
+	})
+	return assignments, nil
+}
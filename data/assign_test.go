@@ -0,0 +1,193 @@
+package data
+
+import (
+	"fmt"
+	"testing"
+)
+
+// scheduleMatches makes qualification matches whose teams are numbered after
+// the match and position, such as 301 for Red 2 of match 3
+func scheduleMatches(count int) []Match {
+	matches := make([]Match, count)
+	for i := range matches {
+		number := i + 1
+		matches[i] = Match{Competition: "2018casj", Number: number,
+			Red:  []int{number*100 + 0, number*100 + 1, number*100 + 2},
+			Blue: []int{number*100 + 3, number*100 + 4, number*100 + 5}}
+	}
+	return matches
+}
+
+func scheduleScouts(count int) []Scout {
+	scouts := make([]Scout, count)
+	for i := range scouts {
+		scouts[i] = Scout{Id: int64(i + 1), Name: fmt.Sprintf("Scout %d", i+1)}
+	}
+	return scouts
+}
+
+func TestScheduleScouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []Match
+		scouts  []Scout
+		rules   ScheduleRules
+		check   func(t *testing.T, assignments []Assignment)
+	}{
+		{"every robot is watched as evenly as possible", scheduleMatches(5), scheduleScouts(8), ScheduleRules{},
+			func(t *testing.T, assignments []Assignment) {
+				if len(assignments) != 5*Positions {
+					t.Errorf("%d robots were watched, want %d", len(assignments), 5*Positions)
+				}
+				for id, load := range scoutLoads(assignments) {
+					if load < 3 || load > 4 {
+						t.Errorf("scout %d watched %d robots, want 3 or 4", id, load)
+					}
+				}
+			}},
+		{"too few scouts leave positions open", scheduleMatches(2), scheduleScouts(4), ScheduleRules{},
+			func(t *testing.T, assignments []Assignment) {
+				perMatch := map[int]int{}
+				for _, assignment := range assignments {
+					perMatch[assignment.Match]++
+				}
+				if perMatch[1] != 4 || perMatch[2] != 4 {
+					t.Errorf("the matches had %v scouts, want 4 each", perMatch)
+				}
+			}},
+		{"scouts get a break after the most matches in a row", scheduleMatches(6), scheduleScouts(9),
+			ScheduleRules{MaxConsecutive: 2},
+			func(t *testing.T, assignments []Assignment) {
+				watched := map[int64]map[int]bool{}
+				for _, assignment := range assignments {
+					if watched[assignment.ScoutId] == nil {
+						watched[assignment.ScoutId] = map[int]bool{}
+					}
+					watched[assignment.ScoutId][assignment.Match] = true
+				}
+				for id, matches := range watched {
+					for match := 3; match <= 6; match++ {
+						if matches[match] && matches[match-1] && matches[match-2] {
+							t.Errorf("scout %d watched matches %d through %d", id, match-2, match)
+						}
+					}
+				}
+			}},
+		{"a break can leave a match without enough scouts", scheduleMatches(3), scheduleScouts(6),
+			ScheduleRules{MaxConsecutive: 1},
+			func(t *testing.T, assignments []Assignment) {
+				for _, assignment := range assignments {
+					if assignment.Match == 2 {
+						t.Errorf("scout %d watched match 2 right after match 1", assignment.ScoutId)
+					}
+				}
+				if len(assignments) != 2*Positions {
+					t.Errorf("%d robots were watched, want %d", len(assignments), 2*Positions)
+				}
+			}},
+		{"scouts don't watch their own team", scheduleMatches(6), withTeams(scheduleScouts(6), 100, 201, 302, 403, 504, 605),
+			ScheduleRules{SkipOwnTeam: true, RotatePositions: true},
+			func(t *testing.T, assignments []Assignment) {
+				for _, assignment := range assignments {
+					if assignment.Team == int(assignment.ScoutId-1)*101+100 {
+						t.Errorf("scout %d watched their own team %d", assignment.ScoutId, assignment.Team)
+					}
+				}
+			}},
+		{"scouts do watch their own team unless told not to", scheduleMatches(1), withTeams(scheduleScouts(6), 100),
+			ScheduleRules{},
+			func(t *testing.T, assignments []Assignment) {
+				if assignments[0].ScoutId != 1 || assignments[0].Team != 100 {
+					t.Errorf("the first robot was assigned as %+v", assignments[0])
+				}
+			}},
+		{"scouts without a team watch any robot", []Match{{Number: 1, Red: []int{0, 0, 0}, Blue: []int{0, 0, 0}}},
+			scheduleScouts(6), ScheduleRules{SkipOwnTeam: true},
+			func(t *testing.T, assignments []Assignment) {
+				if len(assignments) != Positions {
+					t.Errorf("%d robots were watched, want %d", len(assignments), Positions)
+				}
+			}},
+		{"positions rotate", scheduleMatches(6), scheduleScouts(6), ScheduleRules{RotatePositions: true},
+			func(t *testing.T, assignments []Assignment) {
+				for id, positions := range scoutPositions(assignments) {
+					if len(positions) < 4 {
+						t.Errorf("scout %d watched only positions %v", id, positions)
+					}
+				}
+			}},
+		{"positions stay put otherwise", scheduleMatches(6), scheduleScouts(6), ScheduleRules{},
+			func(t *testing.T, assignments []Assignment) {
+				for id, positions := range scoutPositions(assignments) {
+					if len(positions) != 1 {
+						t.Errorf("scout %d moved between positions %v", id, positions)
+					}
+				}
+			}},
+		{"matches without six robots are skipped", []Match{{Number: 1, Red: []int{1, 2}, Blue: []int{3, 4, 5}}},
+			scheduleScouts(6), ScheduleRules{},
+			func(t *testing.T, assignments []Assignment) {
+				if len(assignments) != 0 {
+					t.Errorf("an incomplete match was assigned %+v", assignments)
+				}
+			}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assignments := ScheduleScouts(test.matches, test.scouts, test.rules)
+			for _, assignment := range assignments {
+				match := test.matches[assignment.Match-1]
+				if match.Teams()[assignment.Position] != assignment.Team {
+					t.Fatalf("%+v isn't the team at its position", assignment)
+				}
+			}
+			test.check(t, assignments)
+		})
+	}
+}
+
+func withTeams(scouts []Scout, teams ...int) []Scout {
+	for i, team := range teams {
+		scouts[i].Team = team
+	}
+	return scouts
+}
+
+func scoutLoads(assignments []Assignment) map[int64]int {
+	loads := map[int64]int{}
+	for _, assignment := range assignments {
+		loads[assignment.ScoutId]++
+	}
+	return loads
+}
+
+func scoutPositions(assignments []Assignment) map[int64]map[int]bool {
+	positions := map[int64]map[int]bool{}
+	for _, assignment := range assignments {
+		if positions[assignment.ScoutId] == nil {
+			positions[assignment.ScoutId] = map[int]bool{}
+		}
+		positions[assignment.ScoutId][assignment.Position] = true
+	}
+	return positions
+}
+
+func TestSetUserTeam(t *testing.T) {
+	db := newTestDB(t)
+	admin := &User{Id: addTestUser(t, db, "admin", "a password", true), Admin: true}
+	scout := &User{Id: addTestUser(t, db, "scout", "a password", false)}
+
+	if err := db.SetUserTeam(scout, scout.Id, 254); err != ErrAccessDenied {
+		t.Errorf("a scout setting their team = %v, want ErrAccessDenied", err)
+	}
+	if err := db.SetUserTeam(admin, scout.Id, -1); err != ErrInvalidTeam {
+		t.Errorf("setting a negative team = %v, want ErrInvalidTeam", err)
+	}
+	if err := db.SetUserTeam(admin, scout.Id, 4476); err != nil {
+		t.Fatal(err)
+	}
+	scouts, err := db.GetScouts()
+	if err != nil || len(scouts) != 2 || scouts[1].Team != 4476 {
+		t.Errorf("GetScouts = %+v, %v", scouts, err)
+	}
+}
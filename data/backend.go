@@ -0,0 +1,106 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Backend is where users, their sessions and all scouting data are stored.
+// Every year has a database of its own, and every method of DB runs the same
+// SQL against whichever backend is in use.  Backends only decide where the
+// databases are and how to connect to them; they don't implement the data
+// operations themselves, so every backend must be a SQL database that the
+// migrations of its dialect can build.
+type Backend interface {
+	// Name identifies the kind of backend, such as "mysql"
+	Name() string
+	// Dialect names the dialect of SQL the backend speaks, which decides the
+	// migrations its databases are built with
+	Dialect() string
+	// Provision creates the database of a year if it doesn't exist yet,
+	// without any tables
+	Provision(year int) error
+	// Open connects to the database of a year
+	Open(year int) (*sql.DB, error)
+}
+
+// backend is the Backend every database is connected to
+var backend Backend = MySQL{Prefix: DefaultMySQLPrefix}
+
+// SetBackend chooses where every database is stored from then on
+func SetBackend(b Backend) {
+	backend = b
+}
+
+// NewBackend creates a backend by the name of its kind.  location tells it
+// where to keep the databases and may be empty for the default: for "mysql"
+// it's the data source name up to the year, and for "sqlite" it's the
+// directory holding a file for every year.  "memory" keeps everything in
+// memory until the program exits, which is useful for trying things out.
+func NewBackend(name, location string) (Backend, error) {
+	switch name {
+	case "mysql":
+		if location == "" {
+			location = DefaultMySQLPrefix
+		}
+		return MySQL{Prefix: location}, nil
+	case "sqlite":
+		if location == "" {
+			location = "."
+		}
+		return NewSQLite(location), nil
+	case "memory":
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf("data: unknown backend %q", name)
+}
+
+// DefaultMySQLPrefix is the data source name of the MySQL databases up to
+// the year, such as scouting2018
+const DefaultMySQLPrefix = "scout@/scouting"
+
+// MySQL is a Backend that keeps every year in a database of a MySQL server
+type MySQL struct {
+	// Prefix is the data source name up to the year
+	Prefix string
+}
+
+// Name identifies MySQL as a backend
+func (backend MySQL) Name() string {
+	return "mysql"
+}
+
+// Dialect is MySQL's own
+func (backend MySQL) Dialect() string {
+	return "mysql"
+}
+
+// Provision creates the database of a year on the server, which needs an
+// account allowed to create databases
+func (backend MySQL) Provision(year int) error {
+	config, err := mysql.ParseDSN(backend.Prefix + strconv.Itoa(year))
+	if err != nil {
+		return err
+	}
+	name := config.DBName
+	if name == "" || strings.ContainsRune(name, '`') {
+		return fmt.Errorf("data: can't create a database named %q", name)
+	}
+	config.DBName = ""
+	server, err := sql.Open("mysql", config.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+	_, err = server.Exec("CREATE DATABASE IF NOT EXISTS `" + name + "` DEFAULT CHARACTER SET utf8mb4")
+	return err
+}
+
+// Open connects to the database of a year
+func (backend MySQL) Open(year int) (*sql.DB, error) {
+	return sql.Open("mysql", backend.Prefix+strconv.Itoa(year))
+}
@@ -0,0 +1,152 @@
+package data
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNewBackend(t *testing.T) {
+	for _, name := range []string{"mysql", "sqlite", "memory"} {
+		b, err := NewBackend(name, "")
+		if err != nil || b.Name() != name {
+			t.Errorf("NewBackend(%q) = %v, %v", name, b, err)
+		}
+	}
+	if _, err := NewBackend("postgres", ""); err == nil {
+		t.Error("NewBackend made a backend of an unknown kind")
+	}
+}
+
+func TestMemoryDatabases(t *testing.T) {
+	memory := NewMemory()
+	open := func(b *Memory, year int) DB {
+		db, err := b.Open(year)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return DB{db: db, year: year}
+	}
+
+	addTestUser(t, open(memory, testYear), "scout", "a password", false)
+	if _, err := open(memory, testYear).Login("scout", "a password", ""); err != nil {
+		t.Errorf("a user wasn't kept between connections: %v", err)
+	}
+	if _, err := open(memory, testYear-1).Login("scout", "a password", ""); err != ErrUsernameNotFound {
+		t.Errorf("a user was found in another year: %v", err)
+	}
+	if _, err := open(NewMemory(), testYear).Login("scout", "a password", ""); err != ErrUsernameNotFound {
+		t.Errorf("a user was found in another backend: %v", err)
+	}
+}
+
+func TestMemoryUsersAndSessions(t *testing.T) {
+	db := newTestDB(t)
+	addTestUser(t, db, "admin", "the admin's password", true)
+	if _, err := db.CreateUser("scout", "A Scout", "a password", 4476, "admin", "the admin's password", "phone"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateUser("scout", "Another", "a password", 4476, "admin", "the admin's password", ""); err != ErrUsernameTaken {
+		t.Errorf("creating a user again = %v, want ErrUsernameTaken", err)
+	}
+	if _, err := db.Login("scout", "the wrong password", ""); err != ErrPasswordMismatch {
+		t.Errorf("logging in with the wrong password = %v, want ErrPasswordMismatch", err)
+	}
+
+	laptop, err := db.Login("scout", "a password", "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tablet, err := db.Login("scout", "a password", "tablet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := db.GetUser(requestWith(tablet))
+	if user == nil || user.RealName != "A Scout" || user.Team != 4476 || user.Admin {
+		t.Fatalf("GetUser = %+v", user)
+	}
+
+	sessions, err := db.GetSessions(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices := map[string]bool{}
+	for _, session := range sessions {
+		devices[session.Device] = session.Current
+	}
+	if !reflect.DeepEqual(devices, map[string]bool{"phone": false, "laptop": false, "tablet": true}) {
+		t.Errorf("GetSessions = %+v", sessions)
+	}
+
+	if err = db.RevokeOtherSessions(user); err != nil {
+		t.Fatal(err)
+	}
+	if db.GetUser(requestWith(laptop)) != nil {
+		t.Error("the laptop is still logged in")
+	}
+	if sessions, err = db.GetSessions(user); err != nil || len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("GetSessions after revoking the others = %+v, %v", sessions, err)
+	}
+	if err = db.RevokeSession(user, user.SessionId+100); err != ErrSessionNotFound {
+		t.Errorf("revoking a session that doesn't exist = %v, want ErrSessionNotFound", err)
+	}
+	if err = db.RevokeSession(user, user.SessionId); err != nil || db.GetUser(requestWith(tablet)) != nil {
+		t.Errorf("revoking the tablet's session = %v", err)
+	}
+	if db.Logout(httptest.NewRecorder(), requestWith(tablet)) {
+		t.Error("logged out of a revoked session")
+	}
+}
+
+func TestMemoryScoutingData(t *testing.T) {
+	db := newTestDB(t)
+	scout := &User{Id: addTestUser(t, db, "scout", "a password", false), Username: "scout"}
+
+	comp := Competition{Key: "2018casj", Name: "Silicon Valley Regional", Teams: []int{254, 971, 4476}}
+	if err := db.InsertCompetition(comp); err != nil {
+		t.Fatal(err)
+	}
+	match := Match{Competition: comp.Key, Level: Qualification, Number: 12, Red: []int{4476, 254, 971}, Blue: []int{1, 2, 3}}
+	if err := db.InsertMatch(match); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetMatchScore(comp.Key, Qualification, 12, 310, 120); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := db.GetCompetition(comp.Key)
+	if err != nil || stored.Name != comp.Name || !reflect.DeepEqual(stored.Teams, comp.Teams) {
+		t.Errorf("GetCompetition = %+v, %v", stored, err)
+	}
+	matches, err := db.GetMatches(comp.Key)
+	if err != nil || len(matches) != 1 || !matches[0].Scored || matches[0].RedScore != 310 {
+		t.Errorf("GetMatches = %+v, %v", matches, err)
+	}
+
+	sub := testSubmission()
+	sub.Level, sub.Alliance, sub.ClientId = Qualification, Red, ""
+	if err = db.InsertSubmission(&sub); err != nil {
+		t.Fatal(err)
+	}
+	synced := testSubmission()
+	result, err := db.SyncSubmission(scout, &synced)
+	if err != nil || result.Status != SyncCreated {
+		t.Fatalf("SyncSubmission = %+v, %v", result, err)
+	}
+	again := testSubmission()
+	if result, err = db.SyncSubmission(scout, &again); err != nil || result.Status != SyncDuplicate || result.Id != synced.Id {
+		t.Errorf("syncing again = %+v, %v", result, err)
+	}
+
+	got, err := db.GetSubmission(sub.Id)
+	if err != nil || !reflect.DeepEqual(got.Values, sub.Values) || got.Team != sub.Team || got.Competition != comp.Key {
+		t.Errorf("GetSubmission = %+v, %v", got, err)
+	}
+	subs, err := db.GetSubmissions(SubmissionFilter{Competition: comp.Key, Scout: "scout"})
+	if err != nil || len(subs) != 1 || subs[0].Id != synced.Id || subs[0].ClientId != synced.ClientId {
+		t.Errorf("GetSubmissions of the scout = %+v, %v", subs, err)
+	}
+	if count, err := db.CountSubmissions(SubmissionFilter{Team: sub.Team}); err != nil || count != 2 {
+		t.Errorf("CountSubmissions = %d, %v", count, err)
+	}
+}
@@ -0,0 +1,180 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// Competition represents a single event along with every team attending it
+type Competition struct {
+	Key      string // the event key, such as 2017mndu
+	Name     string
+	Location string
+	Start    Timestamp
+	End      Timestamp
+	Teams    []int
+
+	// Source names the source its official schedule and results are synced
+	// from, or is empty if they're entered by hand
+	Source string
+}
+
+// GetCompetitions retrieves every competition of the year, ordered by the
+// date they start.
+func (db DB) GetCompetitions() ([]Competition, error) {
+	rows, err := db.db.Query(
+		`SELECT event_key, name, location, start_date, end_date, source FROM competitions ORDER BY start_date, event_key`)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.GetCompetitions: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	competitions := []Competition{}
+	indices := map[string]int{}
+	for rows.Next() {
+		var comp Competition
+		err = rows.Scan(&comp.Key, &comp.Name, &comp.Location, &comp.Start, &comp.End, &comp.Source)
+		if err != nil {
+			return nil, err
+		}
+		indices[comp.Key] = len(competitions)
+		competitions = append(competitions, comp)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	teamRows, err := db.db.Query(`SELECT competition, team FROM competition_teams ORDER BY team`)
+	if err != nil {
+		return nil, err
+	}
+	defer teamRows.Close()
+	for teamRows.Next() {
+		var (
+			key  string
+			team int
+		)
+		if err = teamRows.Scan(&key, &team); err != nil {
+			return nil, err
+		}
+		if i, ok := indices[key]; ok {
+			competitions[i].Teams = append(competitions[i].Teams, team)
+		}
+	}
+	return competitions, teamRows.Err()
+}
+
+// GetCompetition retrieves a single competition by its event key.  Returns
+// ErrCompetitionNotFound if there is no such competition.
+func (db DB) GetCompetition(key string) (*Competition, error) {
+	comp := &Competition{}
+	row := db.db.QueryRow(
+		`SELECT event_key, name, location, start_date, end_date, source FROM competitions WHERE event_key=?`, key)
+	err := row.Scan(&comp.Key, &comp.Name, &comp.Location, &comp.Start, &comp.End, &comp.Source)
+	if err == sql.ErrNoRows {
+		return nil, ErrCompetitionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	comp.Teams, err = db.GetCompetitionTeams(key)
+	if err != nil {
+		return nil, err
+	}
+	return comp, nil
+}
+
+// checkCompetition returns ErrInvalidCompetition unless a competition with
+// the event key is stored
+func (db DB) checkCompetition(key string) error {
+	var count int
+	err := db.db.QueryRow(`SELECT COUNT(*) FROM competitions WHERE event_key=?`, key).Scan(&count)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.checkCompetition: "+err.Error())
+		return err
+	}
+	if count == 0 {
+		return ErrInvalidCompetition
+	}
+	return nil
+}
+
+// GetCompetitionTeams retrieves the numbers of every team attending a
+// competition in ascending order.
+func (db DB) GetCompetitionTeams(key string) ([]int, error) {
+	rows, err := db.db.Query(`SELECT team FROM competition_teams WHERE competition=? ORDER BY team`, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []int{}
+	for rows.Next() {
+		var team int
+		if err = rows.Scan(&team); err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, rows.Err()
+}
+
+// InsertCompetition stores a new competition along with its teams
+func (db DB) InsertCompetition(comp Competition) error {
+	if comp.Key == "" || comp.Name == "" {
+		return ErrInvalidCompetition
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	_, err = tx.Exec(
+		`INSERT INTO competitions (event_key, name, location, start_date, end_date, source) VALUES (?, ?, ?, ?, ?, ?)`,
+		comp.Key, comp.Name, comp.Location, comp.Start, comp.End, comp.Source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.InsertCompetition: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	for _, team := range comp.Teams {
+		_, err = tx.Exec(`INSERT INTO competition_teams (competition, team) VALUES (?, ?)`, comp.Key, team)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "data.InsertCompetition: "+err.Error())
+			return ErrDatabaseUpdate
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
+
+// AddCompetitionTeam records that a team is attending a competition
+func (db DB) AddCompetitionTeam(key string, team int) error {
+	if team <= 0 {
+		return ErrInvalidTeam
+	}
+	_, err := db.db.Exec(`INSERT INTO competition_teams (competition, team) VALUES (?, ?)`, key, team)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.AddCompetitionTeam: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
+
+// SetCompetitionSource chooses which source is authoritative for the official
+// schedule and results of a competition.  An empty name stops it from being
+// synced at all.
+func (db DB) SetCompetitionSource(key, source string) error {
+	_, err := db.db.Exec(`UPDATE competitions SET source=? WHERE event_key=?`, source, key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.SetCompetitionSource: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testDate(month time.Month, day int) Timestamp {
+	return Timestamp{time: time.Date(testYear, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestInsertCompetition(t *testing.T) {
+	db := newTestDB(t)
+	casj := Competition{Key: "2018casj", Name: "Silicon Valley Regional", Location: "San Jose, CA",
+		Start: testDate(time.March, 28), End: testDate(time.March, 31), Teams: []int{4476, 254}, Source: "tba"}
+	cada := Competition{Key: "2018cada", Name: "Sacramento Regional",
+		Start: testDate(time.March, 21), End: testDate(time.March, 24)}
+	for _, comp := range []Competition{casj, cada} {
+		if err := db.InsertCompetition(comp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, comp := range []Competition{{Key: "2018cama"}, {Name: "Nameless"}} {
+		if err := db.InsertCompetition(comp); err != ErrInvalidCompetition {
+			t.Errorf("InsertCompetition(%+v) = %v, want ErrInvalidCompetition", comp, err)
+		}
+	}
+	if err := db.InsertCompetition(casj); err != ErrDatabaseUpdate {
+		t.Errorf("inserting a competition twice = %v, want ErrDatabaseUpdate", err)
+	}
+
+	comp, err := db.GetCompetition("2018casj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comp.Name != casj.Name || comp.Location != casj.Location || comp.Source != "tba" ||
+		comp.Start.Date() != "2018-03-28" || comp.End.Date() != "2018-03-31" ||
+		!reflect.DeepEqual(comp.Teams, []int{254, 4476}) {
+		t.Errorf("GetCompetition = %+v", comp)
+	}
+	if _, err = db.GetCompetition("2018cama"); err != ErrCompetitionNotFound {
+		t.Errorf("GetCompetition of an unknown competition = %v, want ErrCompetitionNotFound", err)
+	}
+
+	// competitions come in the order they start
+	comps, err := db.GetCompetitions()
+	if err != nil || len(comps) != 2 || comps[0].Key != "2018cada" || comps[1].Key != "2018casj" {
+		t.Fatalf("GetCompetitions = %+v, %v", comps, err)
+	}
+	if len(comps[0].Teams) != 0 || !reflect.DeepEqual(comps[1].Teams, []int{254, 4476}) {
+		t.Errorf("GetCompetitions found the teams %v and %v", comps[0].Teams, comps[1].Teams)
+	}
+}
+
+func TestAddCompetitionTeam(t *testing.T) {
+	db := newTestDB(t)
+	addTestCompetition(t, db)
+	for _, team := range []int{971, 254} {
+		if err := db.AddCompetitionTeam("2018casj", team); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.AddCompetitionTeam("2018casj", 0); err != ErrInvalidTeam {
+		t.Errorf("adding team 0 = %v, want ErrInvalidTeam", err)
+	}
+	if err := db.AddCompetitionTeam("2018casj", 254); err != ErrDatabaseUpdate {
+		t.Errorf("adding a team twice = %v, want ErrDatabaseUpdate", err)
+	}
+	if teams, err := db.GetCompetitionTeams("2018casj"); err != nil || !reflect.DeepEqual(teams, []int{254, 971}) {
+		t.Errorf("GetCompetitionTeams = %v, %v", teams, err)
+	}
+}
+
+func TestSetCompetitionSource(t *testing.T) {
+	db := newTestDB(t)
+	addTestCompetition(t, db)
+	for _, source := range []string{"frc-events", ""} {
+		if err := db.SetCompetitionSource("2018casj", source); err != nil {
+			t.Fatal(err)
+		}
+		if comp, err := db.GetCompetition("2018casj"); err != nil || comp.Source != source {
+			t.Errorf("after setting the source to %q, GetCompetition = %+v, %v", source, comp, err)
+		}
+	}
+}
@@ -33,6 +33,62 @@ var (
 	// ErrRandGeneration indicates that it was impossible to generate a cryptorandom number
 	ErrRandGeneration = errors.New("could not create a strong random number")
 
+	// ErrInvalidMatch indicates that a submission didn't name a valid match number
+	ErrInvalidMatch = errors.New("invalid match number")
+	// ErrInvalidTeam indicates that a submission didn't name a valid team number
+	ErrInvalidTeam = errors.New("invalid team number")
+	// ErrInvalidAlliance indicates that an alliance color wasn't red or blue
+	ErrInvalidAlliance = errors.New("invalid alliance")
+	// ErrInvalidField indicates that one of the game-specific values in a submission was missing
+	// or out of range
+	ErrInvalidField = errors.New("invalid submission field")
+	// ErrInvalidSeason indicates that a season file didn't describe a usable season
+	ErrInvalidSeason = errors.New("invalid season file")
+	// ErrNoSeason indicates that no season was loaded for the requested game year
+	ErrNoSeason = errors.New("no season defined for the year")
+	// ErrInvalidCompetition indicates that a competition was missing its event key or name, or that
+	// a submission was for a competition that isn't stored
+	ErrInvalidCompetition = errors.New("invalid competition")
+	// ErrCompetitionNotFound indicates that no competition has the requested event key
+	ErrCompetitionNotFound = errors.New("competition not found")
+	// ErrCompetitionYear indicates that a competition's event key is for a year other than the
+	// one the database holds
+	ErrCompetitionYear = errors.New("competition is from another year")
+	// ErrSubmissionNotFound indicates that no submission has the requested id
+	ErrSubmissionNotFound = errors.New("submission not found")
+	// ErrInvalidComposite indicates that a composite score expression couldn't be understood
+	ErrInvalidComposite = errors.New("invalid composite expression")
+	// ErrInvalidPickList indicates that a pick list was unnamed or was reordered with the wrong teams
+	ErrInvalidPickList = errors.New("invalid pick list")
+	// ErrPickListNotFound indicates that no pick list has the requested id
+	ErrPickListNotFound = errors.New("pick list not found")
+	// ErrPitRecordNotFound indicates that a team hasn't been pit scouted at a competition
+	ErrPitRecordNotFound = errors.New("pit record not found")
+	// ErrInvalidClientId indicates that a synced submission's id from the client that made it was
+	// missing, too long or made of characters other than letters, digits, dashes and underscores
+	ErrInvalidClientId = errors.New("invalid client id")
+	// ErrInvalidTransfer indicates that a submission packed for a QR code was damaged
+	ErrInvalidTransfer = errors.New("invalid submission transfer")
+	// ErrTransferVersion indicates that a submission was packed for a QR code by an incompatible
+	// version of the scouting system
+	ErrTransferVersion = errors.New("unsupported submission transfer version")
+	// ErrSourceAccessDenied indicates that a source of official competition data refused the
+	// credentials it was given
+	ErrSourceAccessDenied = errors.New("access denied by competition data source")
+	// ErrPhotoNotFound indicates that no photo has the requested id
+	ErrPhotoNotFound = errors.New("photo not found")
+	// ErrUnsupportedPhoto indicates that an uploaded photo wasn't a JPEG, PNG or GIF image
+	ErrUnsupportedPhoto = errors.New("unsupported photo format")
+	// ErrPhotoTooLarge indicates that an uploaded photo was bigger than MaxPhotoSize or had more
+	// pixels than MaxPhotoPixels
+	ErrPhotoTooLarge = errors.New("photo too large")
+	// ErrInvalidImport indicates that a file being imported wasn't of a kind that can be imported
+	ErrInvalidImport = errors.New("invalid import")
+	// ErrAssignmentNotFound indicates that a user isn't assigned to scout any match that's left
+	ErrAssignmentNotFound = errors.New("assignment not found")
+	// ErrSessionNotFound indicates that a user has no session with the requested id
+	ErrSessionNotFound = errors.New("session not found")
+
 	// ErrNotFound is an HTTP page not found error
 	ErrNotFound = errors.New("page not found")
 	// ErrHTTPMethodUnsupported indicates that a page was requested using an inapropriate HTTP method
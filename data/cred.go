@@ -1,28 +1,22 @@
 package data
 
 import (
-	"crypto/rand"
 	"database/sql"
 	"errors"
 	"fmt"
-	"math"
-	"math/big"
 	"net/http"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
-
-	_ "github.com/go-sql-driver/mysql" // the init function registers a sql driver
 )
 
-const (
-	authCookieName = "USER"
-	bcryptCost     = bcrypt.DefaultCost + 1
-	maxAuthId      = math.MaxInt64
-)
+const authCookieName = "USER"
+
+// bcryptCost is how much work hashing a password takes.  It's only a variable
+// so tests don't spend most of their time hashing.
+var bcryptCost = bcrypt.DefaultCost + 1
 
 // User represents a sinle row from the accounts database
 type User struct {
@@ -30,25 +24,34 @@ type User struct {
 	Username string
 	RealName string
 	GameYear int
+	Admin    bool
+	Team     int // the team the user scouts for
+
+	// SessionId is the session the user was found by
+	SessionId int64
+
+	// Strategist is true for users who build pick lists
+	Strategist bool
 }
 
 // DB represents a connection to the scouting database
 type DB struct {
-	db *sql.DB
+	db   *sql.DB
+	year int
 }
 
 // ConnectToDatabase establishes a connection to the database containing the information for the
-// provided year.  Returns a zero-initialized connection in the case of an error.  Remember to
-// close the connection after you're done with it.
+// provided year, wherever the backend keeps it.  Returns a zero-initialized connection in the case
+// of an error.  Remember to close the connection after you're done with it.
 func ConnectToDatabase(year int) (DB, error) {
 	if !VerifyYear(year) {
 		return DB{}, ErrWrongYear
 	}
-	db, err := sql.Open("mysql", "scout@/scouting"+strconv.Itoa(year))
+	db, err := backend.Open(year)
 	if err != nil {
 		return DB{}, err
 	}
-	return DB{db: db}, nil
+	return DB{db: db, year: year}, nil
 }
 
 // Close frees all resources used by the database connection.
@@ -58,13 +61,15 @@ func (db DB) Close() error {
 
 // Login checks a username and password and returns a secure cookie for future
 // authentication if the user is considered valid.  If not valid, an error is
-// returned indicating the problem.
-func (db DB) Login(username string, password string) (*http.Cookie, error) {
-	var passhash []byte
-	safeUsername := EscapeSQLString(username) // make the username string injection-free
-
-	row := db.db.QueryRow(fmt.Sprintf("SELECT passhash FROM users WHERE username='%s'", safeUsername))
-	err := row.Scan(&passhash)
+// returned indicating the problem.  Every login starts a session of its own,
+// named after the device it came from, so logging in somewhere else doesn't
+// log the user out here.
+func (db DB) Login(username, password, device string) (*http.Cookie, error) {
+	var (
+		id       int64
+		passhash []byte
+	)
+	err := db.db.QueryRow(`SELECT id, passhash FROM users WHERE username=?`, username).Scan(&id, &passhash)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrUsernameNotFound
@@ -75,37 +80,27 @@ func (db DB) Login(username string, password string) (*http.Cookie, error) {
 		return nil, ErrPasswordMismatch
 	}
 
-	authid, err := genAuthId()
-	if err != nil {
-		return nil, err
-	}
-
-	// update the authid
-	_, err = db.db.Exec(fmt.Sprintf("UPDATE users SET authid=%d WHERE username='%s'", authid, username))
-	if err != nil {
-		return nil, ErrDatabaseUpdate
-	}
-
-	return createAuthCookie(username, authid), nil
+	return startSession(db.db, id, device)
 }
 
-// Logout attempts to logout the user specified in the http request.  If the
-// request doesn't contain the valid identity of a user, or the user doesn't
-// exist, Logout returns false.
+// Logout attempts to end the session of the user specified in the http
+// request, leaving their sessions on other devices alone.  If the request
+// doesn't belong to a session, or the session couldn't be ended, Logout
+// returns false.
 func (db DB) Logout(response http.ResponseWriter, request *http.Request) bool {
-	user := db.GetUser(request)
-	if user == nil {
+	cookie := GetAuthCookie(request.Cookies())
+	if cookie == nil {
 		return false
 	}
 
-	http.SetCookie(response, createAuthCookie("", big.NewInt(-1)))
+	http.SetCookie(response, createAuthCookie("", 0))
 
-	query := fmt.Sprintf("UPDATE users SET authid=-1 WHERE username='%s' && authid=%d", user.Username, user.Id)
-	_, err := db.db.Exec(query)
+	result, err := db.db.Exec(`DELETE FROM sessions WHERE token_hash=?`, hashSessionToken(cookie.Value))
 	if err != nil {
 		return false // unable to confirm that the user was logged out
 	}
-	return true
+	ended, err := result.RowsAffected()
+	return err == nil && ended > 0
 }
 
 // GetUser determines if an http request is coming from a client that is currently
@@ -117,49 +112,49 @@ func (db DB) GetUser(request *http.Request) *User {
 	}
 
 	cookie := GetAuthCookie(request.Cookies())
-	if cookie == nil {
-		return nil
-	}
-
-	username, authid := parseAuthCookie(cookie)
-	if username == "" || authid == -1 {
+	if cookie == nil || cookie.Value == "" {
 		return nil
 	}
 
 	var (
-		id       int64
-		realname string
+		user     User
+		lastseen Timestamp
 	)
 	now := Now()
-	row := db.db.QueryRow(fmt.Sprintf(
-		`SELECT id, username, realname
- FROM users
- WHERE username='%s' && authid=%d && lastseen BETWEEN '%s' AND NOW()`, username, authid, now.Add(time.Minute*-30)))
-	err := row.Scan(&id, &username, &realname)
+	row := db.db.QueryRow(`SELECT users.id, username, realname, team, admin, strategist, sessions.id, sessions.lastseen
+ FROM sessions INNER JOIN users ON users.id = sessions.user_id
+ WHERE token_hash=? AND sessions.lastseen > ? AND expires > ?`,
+		hashSessionToken(cookie.Value), now.Add(-sessionIdleTimeout), now)
+	err := row.Scan(&user.Id, &user.Username, &user.RealName, &user.Team, &user.Admin, &user.Strategist,
+		&user.SessionId, &lastseen)
 	if err != nil {
 		return nil
 	}
-
-	return &User{
-		Username: username,
-		RealName: realname,
-		Id:       id,
-		GameYear: now.Year(),
+	user.GameYear = now.Year()
+
+	// only note that the session is in use now and then, rather than writing
+	// to the database on every request
+	if now.Local().Sub(lastseen.Local()) > time.Minute {
+		_, err = db.db.Exec(`UPDATE sessions SET lastseen=? WHERE id=?`, now, user.SessionId)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "data.GetUser: "+err.Error())
+		}
 	}
+
+	return &user
 }
 
 // CreateUser adds a user to the credential store.  Returns the authentication
-// cookie, so no login is required after successfully creating a new user.  If
-// the user could not be created, an error is returned.
-func (db DB) CreateUser(username, realname, password string, team int, adminUsername, adminPassword string) (*http.Cookie, error) {
+// cookie of a session on device, so no login is required after successfully
+// creating a new user.  If the user could not be created, an error is
+// returned.
+func (db DB) CreateUser(username, realname, password string, team int, adminUsername, adminPassword, device string) (*http.Cookie, error) {
 	if !ValidUsername(username) {
 		return nil, ErrInvalidUsername
 	}
 
-	safeAdminUsername := EscapeSQLString(adminUsername)
 	var adminPasshash []byte
-	query := fmt.Sprintf("SELECT passhash FROM users WHERE username='%s' && admin=true", safeAdminUsername)
-	row := db.db.QueryRow(query)
+	row := db.db.QueryRow(`SELECT passhash FROM users WHERE username=? AND admin=true`, adminUsername)
 	if err := row.Scan(&adminPasshash); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrUsernameNotFound
@@ -171,76 +166,103 @@ func (db DB) CreateUser(username, realname, password string, team int, adminUser
 		return nil, ErrAdminPasswordMismatch
 	}
 
-	safeUsername := EscapeSQLString(username)
-	safeRealname := EscapeSQLString(realname)
 	passhash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
 	if err != nil {
 		return nil, err
 	}
 
-	authid, err := genAuthId()
+	tx, err := db.db.Begin()
 	if err != nil {
-		return nil, err
+		fmt.Fprintln(os.Stderr, "data.CreateUser: "+err.Error())
+		return nil, ErrDatabaseUpdate
 	}
+	defer tx.Rollback() // has no effect once committed
 
-	query = fmt.Sprintf(`INSERT INTO users (username, realname, passhash, authid) VALUE ('%s', '%s', '%s', %d)`,
-		safeUsername, safeRealname, string(passhash), authid)
-	_, err = db.db.Exec(query)
+	result, err := tx.Exec(`INSERT INTO users (username, realname, team, passhash) VALUES (?, ?, ?, ?)`,
+		username, realname, team, passhash)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "insert error: "+err.Error())
 		return nil, ErrUsernameTaken
 	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.CreateUser: "+err.Error())
+		return nil, ErrDatabaseUpdate
+	}
 
-	return createAuthCookie(username, authid), nil
+	cookie, err := startSession(tx, id, device)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		fmt.Fprintln(os.Stderr, "data.CreateUser: "+err.Error())
+		return nil, ErrDatabaseUpdate
+	}
+	return cookie, nil
 }
 
-// EscapeSQLString takes a string that would open a vulnerability to sql
-// injections and applies the proper escape sequences to make it safe for use.
-// Do not include leading and trailing quotes in the string.
-func EscapeSQLString(s string) string {
-	s = strings.Replace(s, "\\", "\\\\", -1)
-	s = strings.Replace(s, "'", "\\'", -1)
-	s = strings.Replace(s, "\"", "\\\"", -1)
-	return s
+func createAuthCookie(token string, maxAge int) *http.Cookie {
+	if token == "" {
+		maxAge = -1 // tells the browser to forget the cookie right away
+	}
+	return &http.Cookie{
+		Name:     authCookieName,
+		Secure:   true,
+		HttpOnly: true,
+		Value:    token,
+		MaxAge:   maxAge,
+	}
 }
 
-func genAuthId() (*big.Int, error) {
-	authid, err := rand.Int(rand.Reader, big.NewInt(maxAuthId))
+// GetUsers retrieves every user, ordered by username, for admins to manage
+func (db DB) GetUsers() ([]User, error) {
+	rows, err := db.db.Query(`SELECT id, username, realname, team, admin, strategist FROM users ORDER BY username`)
 	if err != nil {
-		return nil, ErrRandGeneration
+		fmt.Fprintln(os.Stderr, "data.GetUsers: "+err.Error())
+		return nil, err
 	}
-	return authid, nil
-}
+	defer rows.Close()
 
-func createAuthCookie(username string, authid *big.Int) *http.Cookie {
-	return &http.Cookie{
-		Name:   authCookieName,
-		Secure: true,
-		Value:  username + "$" + authid.String(),
-		MaxAge: 7200, // 2 hours in seconds
+	users := []User{}
+	for rows.Next() {
+		var user User
+		if err = rows.Scan(&user.Id, &user.Username, &user.RealName, &user.Team, &user.Admin, &user.Strategist); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
 	}
+	return users, rows.Err()
 }
 
-// parseAuthCookie determines the username and the authid of the user
-// represented by the cookie.  If there is no valid authid, -1 is returned.  If
-// there is no valid username, an empty string is returned.
-func parseAuthCookie(cookie *http.Cookie) (string, int64) {
-	delimeter := strings.LastIndex(cookie.Value, "$")
-	if delimeter == -1 {
-		return "", -1
+// SetStrategist grants a user the strategist role or takes it away.  Only
+// admins may change roles.
+func (db DB) SetStrategist(admin *User, id int64, strategist bool) error {
+	if admin == nil || !admin.Admin {
+		return ErrAccessDenied
 	}
-
-	id, err := strconv.ParseInt(cookie.Value[delimeter+1:], 10, 64)
+	_, err := db.db.Exec(`UPDATE users SET strategist=? WHERE id=?`, strategist, id)
 	if err != nil {
-		id = -1
+		fmt.Fprintln(os.Stderr, "data.SetStrategist: "+err.Error())
+		return ErrDatabaseUpdate
 	}
+	return nil
+}
 
-	username := cookie.Value[:delimeter]
-	if !ValidUsername(username) {
-		username = ""
+// SetUserTeam records the team a user scouts for, or 0 if it isn't known.
+// Only admins may change it for users.
+func (db DB) SetUserTeam(admin *User, id int64, team int) error {
+	if admin == nil || !admin.Admin {
+		return ErrAccessDenied
 	}
-
-	return username, id
+	if team < 0 {
+		return ErrInvalidTeam
+	}
+	_, err := db.db.Exec(`UPDATE users SET team=? WHERE id=?`, team, id)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.SetUserTeam: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	return nil
 }
 
 // ValidUsername checks to make sure that username is an acceptable username
@@ -0,0 +1,229 @@
+package data
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// injections are usernames and passwords shaped like attempts at SQL
+// injection, which must be treated as nothing more than text
+var injections = []string{
+	`' OR '1'='1`,
+	`admin' --`,
+	`admin'/*`,
+	`"; DROP TABLE users; --`,
+	`x' UNION SELECT passhash FROM users WHERE username='admin`,
+	`\' OR 1=1 #`,
+	`%' OR username LIKE '%`,
+	"nul\x00byte",
+	`ünïcödé`,
+	``,
+}
+
+const (
+	fuzzAdmin         = "admin"
+	fuzzAdminPassword = "the admin's password"
+	fuzzVictim        = "victim"
+	fuzzVictimPass    = "the victim's password"
+)
+
+// newFuzzDB creates a database holding an admin and another user whose
+// accounts fuzzed credentials must never get into
+func newFuzzDB(t testing.TB) DB {
+	db := newTestDB(t)
+	addTestUser(t, db, fuzzAdmin, fuzzAdminPassword, true)
+	addTestUser(t, db, fuzzVictim, fuzzVictimPass, false)
+	return db
+}
+
+func requestWith(cookie *http.Cookie) *http.Request {
+	request := httptest.NewRequest("GET", "/", nil)
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+	return request
+}
+
+func FuzzLogin(f *testing.F) {
+	for _, injection := range injections {
+		f.Add(injection, injection)
+		f.Add(fuzzVictim, injection)
+		f.Add(injection, fuzzVictimPass)
+	}
+	db := newFuzzDB(f)
+
+	f.Fuzz(func(t *testing.T, username, password string) {
+		cookie, err := db.Login(username, password, "fuzzer")
+		known := (username == fuzzAdmin && password == fuzzAdminPassword) ||
+			(username == fuzzVictim && password == fuzzVictimPass)
+		if !known {
+			if err != ErrUsernameNotFound && err != ErrPasswordMismatch {
+				t.Fatalf("Login(%q, %q) = %v, want a username or password error", username, password, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("Login(%q, %q) failed: %v", username, password, err)
+		}
+		if user := db.GetUser(requestWith(cookie)); user == nil || user.Username != username {
+			t.Fatalf("Login(%q, %q) logged in as %+v", username, password, user)
+		}
+	})
+}
+
+func FuzzCreateUser(f *testing.F) {
+	for _, injection := range injections {
+		f.Add(injection, injection, injection)
+		f.Add("user"+injection, injection, "device "+injection)
+	}
+	db := newFuzzDB(f)
+
+	f.Fuzz(func(t *testing.T, username, password, device string) {
+		cookie, err := db.CreateUser(username, username, password, 4476, fuzzAdmin, fuzzAdminPassword, device)
+		switch {
+		case !ValidUsername(username):
+			if err != ErrInvalidUsername {
+				t.Fatalf("CreateUser(%q) = %v, want ErrInvalidUsername", username, err)
+			}
+			return
+		case len(password) > 72:
+			if err == nil {
+				t.Fatalf("CreateUser(%q) accepted a password bcrypt can't hash", username)
+			}
+			return
+		case err == ErrUsernameTaken:
+			return // the fuzzer tried the username before
+		case err != nil:
+			t.Fatalf("CreateUser(%q, %q) failed: %v", username, password, err)
+		}
+
+		user := db.GetUser(requestWith(cookie))
+		if user == nil || user.Username != username || user.Admin || user.Team != 4476 {
+			t.Fatalf("CreateUser(%q) logged in as %+v", username, user)
+		}
+		if _, err = db.Login(username, password, device); err != nil {
+			t.Fatalf("Login(%q, %q) after creating the user failed: %v", username, password, err)
+		}
+		if password != fuzzVictimPass {
+			if _, err = db.Login(fuzzVictim, password, device); err != ErrPasswordMismatch {
+				t.Fatalf("Login(victim, %q) = %v, want ErrPasswordMismatch", password, err)
+			}
+		}
+	})
+}
+
+func FuzzCreateUserAdmin(f *testing.F) {
+	for _, injection := range injections {
+		f.Add(injection, injection)
+		f.Add(fuzzAdmin, injection)
+		f.Add(fuzzVictim, fuzzVictimPass)
+	}
+	db := newFuzzDB(f)
+
+	f.Fuzz(func(t *testing.T, adminUsername, adminPassword string) {
+		if adminUsername == fuzzAdmin && adminPassword == fuzzAdminPassword {
+			return
+		}
+		_, err := db.CreateUser("intruder", "Intruder", "a password of course", 1, adminUsername, adminPassword, "")
+		if err != ErrUsernameNotFound && err != ErrAdminPasswordMismatch {
+			t.Fatalf("CreateUser authorized by (%q, %q) = %v, want an admin error", adminUsername, adminPassword, err)
+		}
+	})
+}
+
+func FuzzGetUser(f *testing.F) {
+	for _, injection := range injections {
+		f.Add(injection)
+	}
+	db := newFuzzDB(f)
+	victim, err := db.Login(fuzzVictim, fuzzVictimPass, "laptop")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(victim.Value[:len(victim.Value)-1])
+	f.Add(hashSessionToken(victim.Value))
+
+	f.Fuzz(func(t *testing.T, token string) {
+		if token == victim.Value {
+			return
+		}
+		if user := db.GetUser(requestWith(&http.Cookie{Name: authCookieName, Value: token})); user != nil {
+			t.Fatalf("the token %q authenticated as %+v", token, user)
+		}
+	})
+}
+
+func FuzzLogout(f *testing.F) {
+	for _, injection := range injections {
+		f.Add(injection)
+	}
+	db := newFuzzDB(f)
+	victim, err := db.Login(fuzzVictim, fuzzVictimPass, "laptop")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(hashSessionToken(victim.Value))
+
+	f.Fuzz(func(t *testing.T, token string) {
+		if token == victim.Value {
+			return
+		}
+		request := requestWith(&http.Cookie{Name: authCookieName, Value: token})
+		if db.Logout(httptest.NewRecorder(), request) {
+			t.Fatalf("logging out with the token %q ended a session", token)
+		}
+		if db.GetUser(requestWith(victim)) == nil {
+			t.Fatalf("logging out with the token %q logged the victim out", token)
+		}
+	})
+}
+
+func TestLogoutEndsOnlyThatSession(t *testing.T) {
+	db := newFuzzDB(t)
+	laptop, err := db.Login(fuzzVictim, fuzzVictimPass, "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tablet, err := db.Login(fuzzVictim, fuzzVictimPass, "tablet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !db.Logout(httptest.NewRecorder(), requestWith(tablet)) {
+		t.Fatal("couldn't log out of the tablet")
+	}
+	if db.GetUser(requestWith(tablet)) != nil {
+		t.Error("the tablet is still logged in")
+	}
+	if db.GetUser(requestWith(laptop)) == nil {
+		t.Error("logging out of the tablet logged the laptop out")
+	}
+}
+
+func TestSetStrategist(t *testing.T) {
+	db := newFuzzDB(t)
+	users, err := db.GetUsers()
+	if err != nil || len(users) != 2 || users[0].Username != fuzzAdmin || !users[0].Admin || users[1].Username != fuzzVictim {
+		t.Fatalf("GetUsers = %+v, %v", users, err)
+	}
+	admin, scout := users[0], users[1]
+
+	if err = db.SetStrategist(&scout, scout.Id, true); err != ErrAccessDenied {
+		t.Errorf("a scout making themself a strategist = %v, want ErrAccessDenied", err)
+	}
+	if err = db.SetStrategist(nil, scout.Id, true); err != ErrAccessDenied {
+		t.Errorf("nobody making a strategist = %v, want ErrAccessDenied", err)
+	}
+	for _, strategist := range []bool{true, false} {
+		if err = db.SetStrategist(&admin, scout.Id, strategist); err != nil {
+			t.Fatal(err)
+		}
+		cookie, err := db.Login(fuzzVictim, fuzzVictimPass, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user := db.GetUser(requestWith(cookie)); user == nil || user.Strategist != strategist || user.CanStrategize() != strategist {
+			t.Errorf("after SetStrategist(%v) the user is %+v", strategist, user)
+		}
+	}
+}
@@ -0,0 +1,546 @@
+package data
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ImportSubmissions is the kind of CSV file that holds one submission per row
+	ImportSubmissions = "submissions"
+	// ImportMatches is the kind of CSV file that holds one scheduled match per row
+	ImportMatches = "matches"
+)
+
+// submissionTargets and matchTargets are what the columns of each kind of CSV
+// file may be mapped to, besides the fields of the season for submissions
+var (
+	submissionTargets = []string{"competition", "level", "match", "team", "alliance", "scout", "time"}
+	matchTargets      = []string{"competition", "level", "match", "red1", "red2", "red3", "blue1", "blue2", "blue3",
+		"red_score", "blue_score"}
+)
+
+// importAliases maps headers commonly used by spreadsheets and form tools to
+// the target they most likely mean
+var importAliases = map[string]string{
+	"event":        "competition",
+	"event key":    "competition",
+	"event code":   "competition",
+	"match number": "match",
+	"match #":      "match",
+	"team number":  "team",
+	"team #":       "team",
+	"color":        "alliance",
+	"scouter":      "scout",
+	"username":     "scout",
+	"timestamp":    "time",
+	"red score":    "red_score",
+	"blue score":   "blue_score",
+}
+
+// importTimeLayouts are the forms of time a CSV file may hold, including the
+// one Google Forms uses for its timestamps
+var importTimeLayouts = []string{timestampLayout, time.RFC3339, "2006-01-02", "1/2/2006 15:04:05", "1/2/2006"}
+
+// ImportError describes a problem with one line of a CSV file being imported.
+// Problems with the header or the way columns are mapped are on line 1.
+type ImportError struct {
+	Line    int
+	Message string
+}
+
+func (err ImportError) Error() string {
+	return fmt.Sprintf("line %d: %s", err.Line, err.Message)
+}
+
+// CSVImport is a CSV file of old data on its way into the database, such as
+// responses collected by Google Forms before the scouting system was used.
+type CSVImport struct {
+	Kind   string // ImportSubmissions or ImportMatches
+	Header []string
+	Rows   [][]string
+	Lines  []int // the line each row starts on, since quoted values may span lines
+
+	// Mapping holds the target of every column, which is either one of
+	// ImportTargets or empty to leave the column out
+	Mapping []string
+	// Competition is the event key used for rows without a competition column
+	Competition string
+	// Scout is the username credited with submissions without a scout column
+	Scout string
+}
+
+// ReadCSVImport reads a CSV file of the given kind whose first row names its
+// columns, and maps every column to the target its name suggests.  Cells
+// that exports prefixed with a quote to keep spreadsheets from running them
+// as formulas are read without it.  Syntax errors are returned as an
+// ImportError.
+func ReadCSVImport(kind string, r io.Reader) (*CSVImport, error) {
+	if kind != ImportSubmissions && kind != ImportMatches {
+		return nil, ErrInvalidImport
+	}
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1 // rows of the wrong length are reported along with every other problem
+	imp := &CSVImport{Kind: kind}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, ImportError{parseErr.Line, parseErr.Err.Error()}
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if imp.Header == nil {
+			imp.Header = record
+			continue
+		}
+		for i, cell := range record {
+			if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune("=+-@", rune(cell[1])) {
+				record[i] = cell[1:]
+			}
+		}
+		imp.Rows = append(imp.Rows, record)
+		imp.Lines = append(imp.Lines, line)
+	}
+	if imp.Header == nil {
+		return nil, ImportError{1, "the file is empty"}
+	}
+	imp.Mapping = make([]string, len(imp.Header))
+	return imp, nil
+}
+
+// ImportTargets lists what the columns of a kind of CSV file may be mapped to
+func ImportTargets(kind string, season *Season) []string {
+	if kind == ImportMatches {
+		return matchTargets
+	}
+	return append(append([]string{}, submissionTargets...), fieldNames(season.Fields)...)
+}
+
+func fieldNames(fields []Field) []string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// GuessMapping maps every column to the target whose name, or the label of
+// whose field, matches the column's header regardless of case.  Columns that
+// match nothing, or a target already taken by an earlier column, are left out.
+func (imp *CSVImport) GuessMapping(season *Season) {
+	taken := map[string]bool{}
+	for i, header := range imp.Header {
+		header = strings.ToLower(strings.TrimSpace(header))
+		guess := importAliases[header]
+		for _, target := range ImportTargets(imp.Kind, season) {
+			if guess != "" {
+				break
+			}
+			field := season.Field(target)
+			if header == target || header == strings.Replace(target, "_", " ", -1) ||
+				(field != nil && header == strings.ToLower(field.Label)) {
+				guess = target
+			}
+		}
+		if taken[guess] {
+			guess = ""
+		}
+		imp.Mapping[i] = guess
+		taken[guess] = guess != ""
+	}
+}
+
+// column finds which column is mapped to a target, or -1 if none is
+func (imp *CSVImport) column(target string) int {
+	for i, mapped := range imp.Mapping {
+		if mapped == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkMapping reports the ways in which the columns are mapped that would
+// keep any row from being imported
+func (imp *CSVImport) checkMapping(season *Season) []ImportError {
+	errs := []ImportError{}
+	if len(imp.Mapping) != len(imp.Header) {
+		return append(errs, ImportError{1, "the columns aren't all mapped"})
+	}
+	targets := ImportTargets(imp.Kind, season)
+	seen := map[string]bool{}
+	for i, target := range imp.Mapping {
+		if target == "" {
+			continue
+		}
+		known := false
+		for _, other := range targets {
+			known = known || other == target
+		}
+		if !known {
+			errs = append(errs, ImportError{1, fmt.Sprintf("column %q can't be mapped to %q", imp.Header[i], target)})
+		} else if seen[target] {
+			errs = append(errs, ImportError{1, fmt.Sprintf("more than one column is mapped to %q", target)})
+		}
+		seen[target] = true
+	}
+
+	required := []string{"match", "team", "alliance"}
+	if imp.Kind == ImportMatches {
+		required = []string{"match", "red1", "red2", "red3", "blue1", "blue2", "blue3"}
+	} else {
+		for _, field := range season.Fields {
+			if !field.Validate("") {
+				required = append(required, field.Name)
+			}
+		}
+	}
+	if imp.Competition == "" {
+		required = append([]string{"competition"}, required...)
+	}
+	for _, target := range required {
+		if !seen[target] {
+			errs = append(errs, ImportError{1, fmt.Sprintf("no column is mapped to %q", target)})
+		}
+	}
+	if seen["red_score"] != seen["blue_score"] {
+		errs = append(errs, ImportError{1, "scores need columns for both alliances"})
+	}
+	return errs
+}
+
+// importRow is a row being converted, which remembers the first problem found
+// with it
+type importRow struct {
+	imp    *CSVImport
+	values []string
+	line   int
+	err    *ImportError
+}
+
+func (row *importRow) fail(format string, args ...interface{}) {
+	if row.err == nil {
+		row.err = &ImportError{row.line, fmt.Sprintf(format, args...)}
+	}
+}
+
+// get returns the trimmed value of the column mapped to a target, or an empty
+// string if no column is
+func (row *importRow) get(target string) string {
+	i := row.imp.column(target)
+	if i < 0 || i >= len(row.values) {
+		return ""
+	}
+	return strings.TrimSpace(row.values[i])
+}
+
+func (row *importRow) number(target string) int {
+	value := row.get(target)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		row.fail("%s %q isn't a number", target, value)
+	}
+	return n
+}
+
+func (row *importRow) competition(known map[string]bool) string {
+	key := strings.ToLower(row.get("competition"))
+	if key == "" {
+		key = row.imp.Competition
+	}
+	if !known[key] {
+		row.fail("no competition has the event key %q", key)
+	}
+	return key
+}
+
+func (row *importRow) level() MatchLevel {
+	switch strings.ToLower(row.get("level")) {
+	case "", "qual", "qm", "q", "qualification", "qualifications":
+		return Qualification
+	case "playoff", "playoffs", "elim", "elims", "elimination":
+		return Playoff
+	}
+	row.fail("level %q isn't qual or playoff", row.get("level"))
+	return Qualification
+}
+
+// importValue converts a value from a CSV file into the form it's stored in
+// for the field, accepting the ways spreadsheets tend to write booleans and
+// enum options in any case
+func importValue(field Field, value string) string {
+	switch field.Kind {
+	case FieldBoolean:
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "1", "true", "yes", "y", "x":
+			return "1"
+		case "0", "false", "no", "n", "":
+			return "0"
+		}
+	case FieldCounter:
+		return strings.TrimSpace(value)
+	case FieldEnum:
+		for _, option := range field.Options {
+			if strings.EqualFold(option, strings.TrimSpace(value)) {
+				return option
+			}
+		}
+	}
+	return value
+}
+
+func parseImportTime(value string) (Timestamp, bool) {
+	for _, layout := range importTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return Timestamp{time: t.UTC()}, true
+		}
+	}
+	return Timestamp{}, false
+}
+
+// convert turns every row into a submission or a match.  All problems with
+// the mapping or the rows are returned; the rows are only usable if there are
+// none.
+func (db DB) convert(imp *CSVImport) ([]Submission, []Match, []ImportError, error) {
+	season, err := GetSeason(db.year)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if errs := imp.checkMapping(season); len(errs) > 0 {
+		return nil, nil, errs, nil
+	}
+
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	known := map[string]bool{}
+	for _, comp := range competitions {
+		known[comp.Key] = true
+	}
+	var scouts map[string]int64
+	if imp.Kind == ImportSubmissions {
+		if scouts, err = db.userIds(); err != nil {
+			return nil, nil, nil, err
+		}
+		if _, ok := scouts[imp.Scout]; !ok && imp.column("scout") < 0 {
+			message := fmt.Sprintf("no user is named %q", imp.Scout)
+			if imp.Scout == "" {
+				message = `no column is mapped to "scout"`
+			}
+			return nil, nil, []ImportError{{1, message}}, nil
+		}
+	}
+
+	subs, matches, errs := []Submission{}, []Match{}, []ImportError{}
+	firstLines := map[string]int{}
+	for i, values := range imp.Rows {
+		row := &importRow{imp: imp, values: values, line: imp.Lines[i]}
+		if len(values) != len(imp.Header) {
+			row.fail("there are %d values instead of %d", len(values), len(imp.Header))
+		} else if imp.Kind == ImportMatches {
+			match := row.match(known)
+			key := matchKey(match.Competition, match.Level, match.Number)
+			if first, ok := firstLines[key]; ok && row.err == nil {
+				row.fail("the match is already on line %d", first)
+			}
+			firstLines[key] = row.line
+			matches = append(matches, match)
+		} else {
+			subs = append(subs, row.submission(season, known, scouts))
+		}
+		if row.err != nil {
+			errs = append(errs, *row.err)
+		}
+	}
+	return subs, matches, errs, nil
+}
+
+func (row *importRow) submission(season *Season, known map[string]bool, scouts map[string]int64) Submission {
+	sub := Submission{
+		Competition: row.competition(known),
+		Level:       row.level(),
+		Match:       row.number("match"),
+		Team:        row.number("team"),
+		Time:        Now(),
+		Values:      map[string]string{},
+	}
+	alliance, err := ParseAlliance(strings.ToLower(row.get("alliance")))
+	if err != nil {
+		row.fail("alliance %q isn't red or blue", row.get("alliance"))
+	}
+	sub.Alliance = alliance
+
+	scout := row.get("scout")
+	if scout == "" {
+		scout = row.imp.Scout
+	}
+	if id, ok := scouts[scout]; ok {
+		sub.ScoutId, sub.ScoutName = id, scout
+	} else {
+		row.fail("no user is named %q", scout)
+	}
+	if value := row.get("time"); value != "" {
+		var ok bool
+		if sub.Time, ok = parseImportTime(value); !ok {
+			row.fail("time %q isn't in a known format", value)
+		}
+	}
+
+	for _, field := range season.Fields {
+		value := ""
+		if i := row.imp.column(field.Name); i >= 0 {
+			value = importValue(field, row.values[i])
+		}
+		if !field.Validate(value) {
+			row.fail("%q isn't a valid value for %s", value, field.Name)
+		}
+		sub.Values[field.Name] = value
+	}
+	if row.err == nil && sub.Validate(season) != nil {
+		row.fail("the submission is incomplete")
+	}
+	return sub
+}
+
+func (row *importRow) match(known map[string]bool) Match {
+	match := Match{
+		Competition: row.competition(known),
+		Level:       row.level(),
+		Number:      row.number("match"),
+		Red:         make([]int, 3),
+		Blue:        make([]int, 3),
+	}
+	for i := range match.Red {
+		match.Red[i] = row.number("red" + strconv.Itoa(i+1))
+		match.Blue[i] = row.number("blue" + strconv.Itoa(i+1))
+	}
+	if row.imp.column("red_score") >= 0 && (row.get("red_score") != "" || row.get("blue_score") != "") {
+		match.Scored = true
+		match.RedScore, match.BlueScore = row.number("red_score"), row.number("blue_score")
+	}
+	if match.Number == 0 {
+		row.fail("match numbers start at 1")
+	}
+	for _, team := range match.Teams() {
+		if team == 0 && row.err == nil {
+			row.fail("every alliance needs three teams")
+		}
+	}
+	return match
+}
+
+// userIds maps the username of every user to their id
+func (db DB) userIds() (map[string]int64, error) {
+	rows, err := db.db.Query(`SELECT id, username FROM users`)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.userIds: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	ids := map[string]int64{}
+	for rows.Next() {
+		var (
+			id       int64
+			username string
+		)
+		if err = rows.Scan(&id, &username); err != nil {
+			return nil, err
+		}
+		ids[username] = id
+	}
+	return ids, rows.Err()
+}
+
+// CheckImport finds every problem that would keep the rows of a CSV file
+// from being imported, without storing anything.  An empty list means the
+// file is ready to be imported as it's mapped.
+func (db DB) CheckImport(imp *CSVImport) ([]ImportError, error) {
+	_, _, errs, err := db.convert(imp)
+	return errs, err
+}
+
+// ApplyImport stores every row of a CSV file in a single transaction and
+// returns how many rows were stored.  If any row has a problem, nothing at
+// all is stored and every problem is returned instead.  Submissions keep the
+// scout and time in the file, and matches that are already scheduled are
+// updated to match the file.  Teams playing in imported matches are added to
+// their competition.
+func (db DB) ApplyImport(imp *CSVImport) (int, []ImportError, error) {
+	subs, matches, errs, err := db.convert(imp)
+	if err != nil || len(errs) > 0 {
+		return 0, errs, err
+	}
+
+	attending := map[string]map[int]bool{}
+	known := map[string]Match{}
+	if imp.Kind == ImportMatches {
+		competitions, err := db.GetCompetitions()
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, comp := range competitions {
+			attending[comp.Key] = map[int]bool{}
+			for _, team := range comp.Teams {
+				attending[comp.Key][team] = true
+			}
+			scheduled, err := db.GetMatches(comp.Key)
+			if err != nil {
+				return 0, nil, err
+			}
+			for _, match := range scheduled {
+				known[matchKey(match.Competition, match.Level, match.Number)] = match
+			}
+		}
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	for i := range subs {
+		if _, err = insertSubmission(tx, &subs[i]); err != nil {
+			break
+		}
+	}
+	for _, match := range matches {
+		if err != nil {
+			break
+		}
+		for _, team := range match.Teams() {
+			if err == nil && !attending[match.Competition][team] {
+				_, err = tx.Exec(`INSERT INTO competition_teams (competition, team) VALUES (?, ?)`,
+					match.Competition, team)
+				attending[match.Competition][team] = true
+			}
+		}
+		if err == nil {
+			err = importMatch(tx, match, known)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.ApplyImport: "+err.Error())
+		return 0, nil, ErrDatabaseUpdate
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, nil, ErrDatabaseUpdate
+	}
+	invalidateAnalyses(db.year)
+	return len(subs) + len(matches), nil, nil
+}
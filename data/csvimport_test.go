@@ -0,0 +1,207 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// submissionsCSV is a file of old submissions the way a spreadsheet might
+// hold them, with a comment spanning two lines
+const submissionsCSV = `Event,Match #,Team #,Color,Scouter,Timestamp,Crossed the auto line,auto-switch,auto-scale,switch,scale,vault,endgame,fouls,Comments,Notes
+2018CASJ,1,4476,Red,scout,2018-03-29 10:00:00,yes,1,0,2,3,1,Climbed,0,"fast
+drivetrain",ignored
+2018casj,1,254,blue,scout,3/29/2018 10:01:00,n,0,1,0,5,0,none,1,'=SUM(A1),
+`
+
+func newImportDB(t *testing.T) DB {
+	db := newTestDB(t)
+	t.Cleanup(func() { invalidateAnalyses(db.year) })
+	addTestUser(t, db, "scout", "a password", false)
+	if err := db.InsertCompetition(Competition{Key: "2018casj", Name: "Silicon Valley Regional", Teams: []int{254}}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func readImport(t *testing.T, kind, contents string) *CSVImport {
+	imp, err := ReadCSVImport(kind, strings.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp.GuessMapping(mustSeason())
+	return imp
+}
+
+func TestReadCSVImport(t *testing.T) {
+	imp := readImport(t, ImportSubmissions, submissionsCSV)
+	if len(imp.Rows) != 2 || !reflect.DeepEqual(imp.Lines, []int{2, 4}) {
+		t.Fatalf("read %d rows starting on lines %v, want 2 rows on lines 2 and 4", len(imp.Rows), imp.Lines)
+	}
+	if comments := imp.Rows[0][14]; comments != "fast\ndrivetrain" {
+		t.Errorf("the first comment was read as %q", comments)
+	}
+	// exports escape formulas, which are read back as they were
+	if comments := imp.Rows[1][14]; comments != "=SUM(A1)" {
+		t.Errorf("the escaped comment was read as %q", comments)
+	}
+
+	if _, err := ReadCSVImport("pictures", strings.NewReader(submissionsCSV)); err != ErrInvalidImport {
+		t.Errorf("reading a file of an unknown kind = %v, want ErrInvalidImport", err)
+	}
+	if _, err := ReadCSVImport(ImportMatches, strings.NewReader("")); err != (ImportError{1, "the file is empty"}) {
+		t.Errorf("reading an empty file = %v", err)
+	}
+	_, err := ReadCSVImport(ImportMatches, strings.NewReader("match,red1\n1,254\n2,\"4476\n3,971 \"x\n"))
+	if importErr, ok := err.(ImportError); !ok || importErr.Line != 4 {
+		t.Errorf("reading a file with a stray quote = %v, want an error on line 4", err)
+	}
+	kept, err := ReadCSVImport(ImportMatches, strings.NewReader("note\n'quoted\n'\n"))
+	if err != nil || kept.Rows[0][0] != "'quoted" || kept.Rows[1][0] != "'" {
+		t.Errorf("quotes that don't escape formulas were read as %v, %v", kept.Rows, err)
+	}
+}
+
+func TestGuessMapping(t *testing.T) {
+	imp := readImport(t, ImportSubmissions, submissionsCSV)
+	want := []string{"competition", "match", "team", "alliance", "scout", "time", "auto-run", "auto-switch",
+		"auto-scale", "switch", "scale", "vault", "endgame", "fouls", "comments", ""}
+	if !reflect.DeepEqual(imp.Mapping, want) {
+		t.Errorf("GuessMapping = %q, want %q", imp.Mapping, want)
+	}
+
+	imp = readImport(t, ImportMatches, "Event Key,Match,Red 1,red1,Red Score,Blue Score,team\n")
+	want = []string{"competition", "match", "", "red1", "red_score", "blue_score", ""}
+	if !reflect.DeepEqual(imp.Mapping, want) {
+		t.Errorf("GuessMapping of matches = %q, want %q", imp.Mapping, want)
+	}
+}
+
+func TestCheckImportMapping(t *testing.T) {
+	db := newImportDB(t)
+	imp := readImport(t, ImportMatches, "competition,match,red1,red2,red3,blue1,blue2,team,red_score\n")
+	imp.Mapping[7] = "team"
+	errs, err := db.CheckImport(imp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ImportError{
+		{1, `column "team" can't be mapped to "team"`},
+		{1, `no column is mapped to "blue3"`},
+		{1, "scores need columns for both alliances"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("CheckImport = %v, want %v", errs, want)
+	}
+
+	imp = readImport(t, ImportSubmissions, "match,team,alliance\n")
+	errs, err = db.CheckImport(imp)
+	if err != nil || len(errs) == 0 || errs[0] != (ImportError{1, `no column is mapped to "competition"`}) {
+		t.Errorf("CheckImport without a competition = %v, %v", errs, err)
+	}
+}
+
+func TestCheckImportRows(t *testing.T) {
+	db := newImportDB(t)
+	contents := submissionsCSV + `2018nope,2,4476,red,scout,,1,0,0,0,0,0,none,0,,
+2018casj,two,4476,green,nobody,yesterday,1,0,0,0,0,0,none,0,,
+2018casj,3,4476,red,scout,,maybe,0,0,0,99,0,none,0,,
+2018casj,4,4476,red
+`
+	errs, err := db.CheckImport(readImport(t, ImportSubmissions, contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ImportError{
+		{5, `no competition has the event key "2018nope"`},
+		{6, `match "two" isn't a number`},
+		{7, `"maybe" isn't a valid value for auto-run`},
+		{8, "there are 4 values instead of 16"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("CheckImport = %v, want %v", errs, want)
+	}
+
+	imp := readImport(t, ImportMatches, "competition,match,red1,red2,red3,blue1,blue2,blue3\n2018casj,1,1,2,3,4,5,6\n2018casj,1,1,2,3,4,5,6\n")
+	if errs, err = db.CheckImport(imp); err != nil || !reflect.DeepEqual(errs, []ImportError{{3, "the match is already on line 2"}}) {
+		t.Errorf("CheckImport of a repeated match = %v, %v", errs, err)
+	}
+}
+
+func TestApplyImportSubmissions(t *testing.T) {
+	db := newImportDB(t)
+	imported, errs, err := db.ApplyImport(readImport(t, ImportSubmissions, submissionsCSV))
+	if err != nil || len(errs) > 0 || imported != 2 {
+		t.Fatalf("ApplyImport = %d, %v, %v", imported, errs, err)
+	}
+	subs, err := db.GetSubmissions(SubmissionFilter{Competition: "2018casj", Scout: "scout"})
+	if err != nil || len(subs) != 2 {
+		t.Fatalf("GetSubmissions = %+v, %v", subs, err)
+	}
+	byTeam := map[int]Submission{}
+	for _, sub := range subs {
+		byTeam[sub.Team] = sub
+	}
+	first, second := byTeam[4476], byTeam[254]
+	if first.Alliance != Red || first.Time.String() != "2018-03-29 10:00:00" || first.Values["auto-run"] != "1" ||
+		first.Values["endgame"] != "climbed" || first.Values["comments"] != "fast\ndrivetrain" {
+		t.Errorf("the first submission was imported as %+v", first)
+	}
+	if second.Alliance != Blue || second.Time.String() != "2018-03-29 10:01:00" || second.Values["comments"] != "=SUM(A1)" {
+		t.Errorf("the second submission was imported as %+v", second)
+	}
+}
+
+func TestApplyImportAllOrNothing(t *testing.T) {
+	db := newImportDB(t)
+	contents := submissionsCSV + "2018casj,2,4476,purple,scout,,1,0,0,0,0,0,none,0,,\n"
+	imported, errs, err := db.ApplyImport(readImport(t, ImportSubmissions, contents))
+	if err != nil || imported != 0 || !reflect.DeepEqual(errs, []ImportError{{5, `alliance "purple" isn't red or blue`}}) {
+		t.Errorf("ApplyImport with a bad row = %d, %v, %v", imported, errs, err)
+	}
+	if count, err := db.CountSubmissions(SubmissionFilter{}); err != nil || count != 0 {
+		t.Errorf("%d submissions were stored despite the bad row, err %v", count, err)
+	}
+
+	// a failure partway through the transaction stores nothing either
+	if _, err = db.db.Exec(`DROP TABLE submission_values`); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = db.ApplyImport(readImport(t, ImportSubmissions, submissionsCSV)); err != ErrDatabaseUpdate {
+		t.Errorf("ApplyImport without a values table = %v, want ErrDatabaseUpdate", err)
+	}
+	var count int
+	if err = db.db.QueryRow(`SELECT COUNT(*) FROM submissions`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("%d submissions were stored by a failed import, err %v", count, err)
+	}
+}
+
+func TestApplyImportMatches(t *testing.T) {
+	db := newImportDB(t)
+	if err := db.InsertMatch(Match{Competition: "2018casj", Number: 1, Red: []int{9, 9, 9}, Blue: []int{9, 9, 9}}); err != nil {
+		t.Fatal(err)
+	}
+	contents := `competition,level,match,red1,red2,red3,blue1,blue2,blue3,red_score,blue_score
+2018casj,qm,1,1,2,3,4,5,6,100,90
+2018casj,elim,1,254,2,3,4,5,6,,
+`
+	imported, errs, err := db.ApplyImport(readImport(t, ImportMatches, contents))
+	if err != nil || len(errs) > 0 || imported != 2 {
+		t.Fatalf("ApplyImport = %d, %v, %v", imported, errs, err)
+	}
+	matches, err := db.GetMatches("2018casj")
+	if err != nil || len(matches) != 2 {
+		t.Fatalf("GetMatches = %+v, %v", matches, err)
+	}
+	qual, playoff := matches[0], matches[1]
+	if !reflect.DeepEqual(qual.Red, []int{1, 2, 3}) || !qual.Scored || qual.RedScore != 100 || qual.BlueScore != 90 {
+		t.Errorf("the scheduled match was imported as %+v", qual)
+	}
+	if playoff.Level != Playoff || playoff.Scored || playoff.Red[0] != 254 {
+		t.Errorf("the playoff was imported as %+v", playoff)
+	}
+	teams, err := db.GetCompetitionTeams("2018casj")
+	if err != nil || !reflect.DeepEqual(teams, []int{1, 2, 3, 4, 5, 6, 254}) {
+		t.Errorf("the competition's teams are %v, %v", teams, err)
+	}
+}
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"os"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testYear is the year of the databases tests use, which has a season file
+const testYear = 2018
+
+func TestMain(m *testing.M) {
+	bcryptCost = bcrypt.MinCost
+	if err := LoadSeasons("../seasons"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestDB connects to an empty database of testYear, held in memory by a
+// backend of its own so tests don't see each other's data
+func newTestDB(t testing.TB) DB {
+	db, err := NewMemory().Open(testYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return DB{db: db, year: testYear}
+}
+
+// addTestUser adds a user straight to the database, returning their id
+func addTestUser(t testing.TB, db DB, username, password string, admin bool) int64 {
+	passhash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := db.db.Exec(`INSERT INTO users (username, realname, passhash, admin) VALUES (?, ?, ?, ?)`,
+		username, username, passhash, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// addTestCompetition adds the competition of testSubmission
+func addTestCompetition(t testing.TB, db DB) {
+	if err := db.InsertCompetition(Competition{Key: "2018casj", Name: "Silicon Valley Regional"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,181 @@
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// SubmissionEdit records a single change made to a submission after it was
+// first submitted.
+type SubmissionEdit struct {
+	EditorId   int64
+	EditorName string
+	Time       Timestamp
+
+	// Previous is the submission as it was before the edit was made
+	Previous Submission
+}
+
+// snapshot is the form in which the previous version of an edited submission
+// is stored
+type snapshot struct {
+	Competition string            `json:"competition"`
+	Level       string            `json:"level"`
+	Match       int               `json:"match"`
+	Team        int               `json:"team"`
+	Alliance    string            `json:"alliance"`
+	Values      map[string]string `json:"values"`
+}
+
+// EditableBy reports whether the user is allowed to edit or delete the
+// submission.  Only the scout who made it and admins may do so.
+func (sub Submission) EditableBy(user *User) bool {
+	return user != nil && (user.Admin || user.Id == sub.ScoutId)
+}
+
+// UpdateSubmission replaces the contents of an existing submission, keeping
+// the previous version in its edit history.  The scout and time of the
+// original submission are left unchanged.  Returns ErrAccessDenied if the
+// editor isn't allowed to change the submission.
+func (db DB) UpdateSubmission(editor *User, sub *Submission) error {
+	old, err := db.GetSubmission(sub.Id)
+	if err != nil {
+		return err
+	}
+	if !old.EditableBy(editor) {
+		return ErrAccessDenied
+	}
+	season, err := GetSeason(db.year)
+	if err != nil {
+		return err
+	}
+	if err = sub.Validate(season); err != nil {
+		return err
+	}
+	if err = db.checkCompetition(sub.Competition); err != nil {
+		return err
+	}
+
+	previous, err := json.Marshal(snapshot{
+		Competition: old.Competition,
+		Level:       old.Level.String(),
+		Match:       old.Match,
+		Team:        old.Team,
+		Alliance:    old.Alliance.String(),
+		Values:      old.Values,
+	})
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	_, err = tx.Exec(`INSERT INTO submission_edits (submission, editor, edited, previous) VALUES (?, ?, ?, ?)`,
+		sub.Id, editor.Id, Now(), string(previous))
+	if err == nil {
+		_, err = tx.Exec(`UPDATE submissions SET competition=?, level=?, match_number=?, team=?, alliance=? WHERE id=?`,
+			sub.Competition, sub.Level.String(), sub.Match, sub.Team, sub.Alliance.String(), sub.Id)
+	}
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM submission_values WHERE submission=?`, sub.Id)
+	}
+	for name, value := range sub.Values {
+		if err != nil {
+			break
+		}
+		_, err = tx.Exec(`INSERT INTO submission_values (submission, field, value) VALUES (?, ?, ?)`, sub.Id, name, value)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.UpdateSubmission: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+
+	if err = tx.Commit(); err != nil {
+		return ErrDatabaseUpdate
+	}
+	invalidateAnalyses(db.year)
+	sub.ScoutId, sub.ScoutName, sub.Time = old.ScoutId, old.ScoutName, old.Time
+	return nil
+}
+
+// DeleteSubmission removes a submission along with its values and edit
+// history.  Returns ErrAccessDenied if the user isn't allowed to delete it.
+func (db DB) DeleteSubmission(user *User, id int64) error {
+	sub, err := db.GetSubmission(id)
+	if err != nil {
+		return err
+	}
+	if !sub.EditableBy(user) {
+		return ErrAccessDenied
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	for _, query := range []string{
+		`DELETE FROM submission_values WHERE submission=?`,
+		`DELETE FROM submission_edits WHERE submission=?`,
+		`DELETE FROM submissions WHERE id=?`,
+	} {
+		if _, err = tx.Exec(query, id); err != nil {
+			fmt.Fprintln(os.Stderr, "data.DeleteSubmission: "+err.Error())
+			return ErrDatabaseUpdate
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return ErrDatabaseUpdate
+	}
+	invalidateAnalyses(db.year)
+	return nil
+}
+
+// GetSubmissionEdits retrieves the edit history of a submission, oldest
+// first.
+func (db DB) GetSubmissionEdits(id int64) ([]SubmissionEdit, error) {
+	rows, err := db.db.Query(`SELECT e.editor, u.username, e.edited, e.previous
+ FROM submission_edits e LEFT JOIN users u ON u.id=e.editor
+ WHERE e.submission=? ORDER BY e.edited, e.id`, id)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.GetSubmissionEdits: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	edits := []SubmissionEdit{}
+	for rows.Next() {
+		var (
+			edit       SubmissionEdit
+			editorName sql.NullString
+			previous   string
+			snap       snapshot
+		)
+		if err = rows.Scan(&edit.EditorId, &editorName, &edit.Time, &previous); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(previous), &snap); err != nil {
+			return nil, err
+		}
+		edit.EditorName = editorName.String
+		edit.Previous = Submission{
+			Id:          id,
+			Competition: snap.Competition,
+			Match:       snap.Match,
+			Team:        snap.Team,
+			Values:      snap.Values,
+		}
+		edit.Previous.Level, _ = ParseMatchLevel(snap.Level)
+		edit.Previous.Alliance, _ = ParseAlliance(snap.Alliance)
+		edits = append(edits, edit)
+	}
+	return edits, rows.Err()
+}
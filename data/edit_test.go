@@ -0,0 +1,140 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newEditDB makes a database with a submission by the scout "owner", along
+// with the user who made it, another scout and an admin
+func newEditDB(t *testing.T) (db DB, sub *Submission, owner, foreign, admin *User) {
+	db = newTestDB(t)
+	addTestCompetition(t, db)
+	owner = &User{Id: addTestUser(t, db, "owner", "a password", false)}
+	foreign = &User{Id: addTestUser(t, db, "foreign", "a password", false)}
+	admin = &User{Id: addTestUser(t, db, "admin", "a password", true), Admin: true}
+
+	sub = &Submission{}
+	*sub = testSubmission()
+	sub.ScoutId = owner.Id
+	if err := db.InsertSubmission(sub); err != nil {
+		t.Fatal(err)
+	}
+	return db, sub, owner, foreign, admin
+}
+
+func TestEditableBy(t *testing.T) {
+	sub := testSubmission()
+	tests := []struct {
+		user *User
+		want bool
+	}{
+		{nil, false},
+		{&User{Id: 8}, false},
+		{&User{Id: 7}, true},
+		{&User{Id: 8, Admin: true}, true},
+	}
+	for _, test := range tests {
+		if editable := sub.EditableBy(test.user); editable != test.want {
+			t.Errorf("EditableBy(%+v) = %v, want %v", test.user, editable, test.want)
+		}
+	}
+}
+
+func TestUpdateSubmission(t *testing.T) {
+	db, original, owner, foreign, admin := newEditDB(t)
+
+	edited := *original
+	edited.Team, edited.Values = 254, map[string]string{}
+	for name, value := range original.Values {
+		edited.Values[name] = value
+	}
+	edited.Values["scale"] = "12"
+	if err := db.UpdateSubmission(foreign, &edited); err != ErrAccessDenied {
+		t.Errorf("another scout's edit = %v, want ErrAccessDenied", err)
+	}
+	edited.Values["fouls"] = "lots"
+	if err := db.UpdateSubmission(owner, &edited); err != ErrInvalidField {
+		t.Errorf("an invalid edit = %v, want ErrInvalidField", err)
+	}
+	if edits, err := db.GetSubmissionEdits(original.Id); err != nil || len(edits) != 0 {
+		t.Fatalf("rejected edits left the history %+v, %v", edits, err)
+	}
+
+	edited.Values["fouls"] = "0"
+	edited.Competition = "2018cada"
+	if err := db.UpdateSubmission(owner, &edited); err != ErrInvalidCompetition {
+		t.Errorf("moving the submission to a competition that isn't stored = %v, want ErrInvalidCompetition", err)
+	}
+	edited.Competition = "2018casj"
+	if err := db.UpdateSubmission(owner, &edited); err != nil {
+		t.Fatal(err)
+	}
+	second := edited
+	second.Alliance = Red
+	if err := db.UpdateSubmission(admin, &second); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := db.GetSubmission(original.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Team != 254 || stored.Alliance != Red || stored.Values["scale"] != "12" ||
+		stored.ScoutId != owner.Id || stored.ScoutName != "owner" || stored.Time.String() != original.Time.String() {
+		t.Errorf("the edited submission is %+v", stored)
+	}
+
+	edits, err := db.GetSubmissionEdits(original.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 2 || edits[0].EditorName != "owner" || edits[1].EditorId != admin.Id {
+		t.Fatalf("GetSubmissionEdits = %+v", edits)
+	}
+	for i, want := range []Submission{*original, edited} {
+		previous := edits[i].Previous
+		if previous.Id != original.Id || previous.Competition != want.Competition || previous.Level != want.Level ||
+			previous.Match != want.Match || previous.Team != want.Team || previous.Alliance != want.Alliance ||
+			!reflect.DeepEqual(previous.Values, want.Values) {
+			t.Errorf("edit %d kept %+v, want %+v", i, previous, want)
+		}
+	}
+}
+
+func TestDeleteSubmission(t *testing.T) {
+	db, sub, owner, foreign, admin := newEditDB(t)
+	edited := *sub
+	edited.Match = 13
+	if err := db.UpdateSubmission(owner, &edited); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.DeleteSubmission(foreign, sub.Id); err != ErrAccessDenied {
+		t.Errorf("another scout's delete = %v, want ErrAccessDenied", err)
+	}
+	if _, err := db.GetSubmission(sub.Id); err != nil {
+		t.Errorf("a denied delete removed the submission: %v", err)
+	}
+	if err := db.DeleteSubmission(admin, sub.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetSubmission(sub.Id); err != ErrSubmissionNotFound {
+		t.Errorf("GetSubmission after the delete = %v, want ErrSubmissionNotFound", err)
+	}
+	if edits, err := db.GetSubmissionEdits(sub.Id); err != nil || len(edits) != 0 {
+		t.Errorf("the deleted submission's history is %+v, %v", edits, err)
+	}
+	if err := db.DeleteSubmission(admin, sub.Id); err != ErrSubmissionNotFound {
+		t.Errorf("deleting it again = %v, want ErrSubmissionNotFound", err)
+	}
+
+	mine := testSubmission()
+	mine.ScoutId = owner.Id
+	if err := db.InsertSubmission(&mine); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteSubmission(owner, mine.Id); err != nil {
+		t.Errorf("the owner's delete = %v", err)
+	}
+}
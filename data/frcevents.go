@@ -0,0 +1,218 @@
+package data
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FRCEventsBaseURL is where version 2 of the FIRST FRC Events API is served
+const FRCEventsBaseURL = "https://frc-api.firstinspires.org/v2.0"
+
+// FRCEvents is a Source backed by the FIRST FRC Events API, which often has
+// official results before The Blue Alliance does.  It names events by season
+// and event code instead of by key, so keys such as 2018wasno are split into
+// the season 2018 and the code WASNO.
+type FRCEvents struct {
+	baseURL  string
+	username string
+	authKey  string
+	client   *http.Client
+	cache    sourceCache
+}
+
+// NewFRCEvents creates a client for the FRC Events API served at baseURL,
+// which is normally FRCEventsBaseURL.  Requests are authorized with the
+// username and authorization key of a registered API account.
+func NewFRCEvents(baseURL, username, authKey string) *FRCEvents {
+	return &FRCEvents{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		username: username,
+		authKey:  authKey,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name identifies the FRC Events API as a source
+func (frc *FRCEvents) Name() string {
+	return "frc"
+}
+
+type frcEvent struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	City      string `json:"city"`
+	StateProv string `json:"stateprov"`
+	Country   string `json:"country"`
+	DateStart string `json:"dateStart"`
+	DateEnd   string `json:"dateEnd"`
+}
+
+func (event frcEvent) competition(season int) Competition {
+	location := []string{}
+	for _, part := range []string{event.City, event.StateProv, event.Country} {
+		if part != "" {
+			location = append(location, part)
+		}
+	}
+	return Competition{
+		Key:      strconv.Itoa(season) + strings.ToLower(event.Code),
+		Name:     event.Name,
+		Location: strings.Join(location, ", "),
+		Start:    parseSourceDate(event.DateStart),
+		End:      parseSourceDate(event.DateEnd),
+	}
+}
+
+// frcScheduledMatch is a match of the hybrid schedule, which includes the
+// final scores of matches that have been played
+type frcScheduledMatch struct {
+	MatchNumber    int  `json:"matchNumber"`
+	ScoreRedFinal  *int `json:"scoreRedFinal"`
+	ScoreBlueFinal *int `json:"scoreBlueFinal"`
+	Teams          []struct {
+		TeamNumber int    `json:"teamNumber"`
+		Station    string `json:"station"`
+	} `json:"teams"`
+}
+
+func (frc *FRCEvents) get(path string, query url.Values, v interface{}) error {
+	target := frc.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	request, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth(frc.username, frc.authKey)
+	return frc.cache.getJSON(frc.client, request, v)
+}
+
+// splitEventKey separates an event key into its season and FRC event code
+func splitEventKey(key string) (int, string, error) {
+	if len(key) <= 4 {
+		return 0, "", ErrCompetitionNotFound
+	}
+	season, err := strconv.Atoi(key[:4])
+	if err != nil {
+		return 0, "", ErrCompetitionNotFound
+	}
+	return season, strings.ToUpper(key[4:]), nil
+}
+
+// Events lists every competition of a year
+func (frc *FRCEvents) Events(year int) ([]Competition, error) {
+	var response struct {
+		Events []frcEvent `json:"Events"`
+	}
+	if err := frc.get("/"+strconv.Itoa(year)+"/events", nil, &response); err != nil {
+		return nil, err
+	}
+	competitions := make([]Competition, 0, len(response.Events))
+	for _, event := range response.Events {
+		competitions = append(competitions, event.competition(year))
+	}
+	sort.Slice(competitions, func(i, j int) bool {
+		return competitions[i].Start.time.Before(competitions[j].Start.time)
+	})
+	return competitions, nil
+}
+
+// Event retrieves a single competition
+func (frc *FRCEvents) Event(key string) (*Competition, error) {
+	season, code, err := splitEventKey(key)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Events []frcEvent `json:"Events"`
+	}
+	err = frc.get("/"+strconv.Itoa(season)+"/events", url.Values{"eventCode": {code}}, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Events) == 0 {
+		return nil, ErrCompetitionNotFound
+	}
+	comp := response.Events[0].competition(season)
+	return &comp, nil
+}
+
+// EventTeams lists the number of every team attending a competition.  The
+// API hands out teams a page at a time.
+func (frc *FRCEvents) EventTeams(key string) ([]int, error) {
+	season, code, err := splitEventKey(key)
+	if err != nil {
+		return nil, err
+	}
+	teams := []int{}
+	for page, pages := 1, 1; page <= pages; page++ {
+		var response struct {
+			Teams []struct {
+				TeamNumber int `json:"teamNumber"`
+			} `json:"teams"`
+			PageTotal int `json:"pageTotal"`
+		}
+		query := url.Values{"eventCode": {code}, "page": {strconv.Itoa(page)}}
+		if err = frc.get("/"+strconv.Itoa(season)+"/teams", query, &response); err != nil {
+			return nil, err
+		}
+		for _, team := range response.Teams {
+			teams = append(teams, team.TeamNumber)
+		}
+		pages = response.PageTotal
+	}
+	sort.Ints(teams)
+	return teams, nil
+}
+
+// EventMatches lists every match of a competition.  Playoff matches are
+// numbered through the whole playoffs by the API already.
+func (frc *FRCEvents) EventMatches(key string) ([]Match, error) {
+	season, code, err := splitEventKey(key)
+	if err != nil {
+		return nil, err
+	}
+	matches := []Match{}
+	for _, level := range []MatchLevel{Qualification, Playoff} {
+		var response struct {
+			Schedule []frcScheduledMatch `json:"Schedule"`
+		}
+		path := "/" + strconv.Itoa(season) + "/schedule/" + code + "/" + level.String() + "/hybrid"
+		if err = frc.get(path, nil, &response); err != nil {
+			return nil, err
+		}
+		for _, scheduled := range response.Schedule {
+			matches = append(matches, scheduled.match(level))
+		}
+	}
+	return matches, nil
+}
+
+func (scheduled frcScheduledMatch) match(level MatchLevel) Match {
+	match := Match{Level: level, Number: scheduled.MatchNumber, Red: make([]int, 3), Blue: make([]int, 3)}
+	for _, team := range scheduled.Teams {
+		// stations are named Red1 through Blue3
+		if len(team.Station) < 2 {
+			continue
+		}
+		position := int(team.Station[len(team.Station)-1] - '1')
+		if position < 0 || position > 2 {
+			continue
+		}
+		if strings.HasPrefix(team.Station, "Red") {
+			match.Red[position] = team.TeamNumber
+		} else if strings.HasPrefix(team.Station, "Blue") {
+			match.Blue[position] = team.TeamNumber
+		}
+	}
+	if scheduled.ScoreRedFinal != nil && scheduled.ScoreBlueFinal != nil {
+		match.Scored = true
+		match.RedScore, match.BlueScore = *scheduled.ScoreRedFinal, *scheduled.ScoreBlueFinal
+	}
+	return match
+}
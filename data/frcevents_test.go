@@ -0,0 +1,103 @@
+package data
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func newFakeFRCEvents(t *testing.T) (*fakeAPI, *FRCEvents) {
+	api, server := newFakeAPI(t, func(request *http.Request) bool {
+		username, authKey, ok := request.BasicAuth()
+		return ok && username == "scout" && authKey == "the authorization key"
+	})
+	// the fake ignores query strings, so the events path answers for one event
+	api.set("/v2.0/2018/events", `{"Events": [{
+		"code": "CASJ", "name": "Silicon Valley Regional", "city": "San Jose", "stateprov": "CA",
+		"country": "USA", "dateStart": "2018-03-28T00:00:00", "dateEnd": "2018-03-31T23:59:59"
+	}]}`)
+	api.set("/v2.0/2018/teams", `{"teams": [{"teamNumber": 4476}, {"teamNumber": 254}, {"teamNumber": 971}], "pageTotal": 1}`)
+	api.set("/v2.0/2018/schedule/CASJ/qual/hybrid", `{"Schedule": [
+		{"matchNumber": 1, "scoreRedFinal": 310, "scoreBlueFinal": 120, "teams": [
+			{"teamNumber": 4476, "station": "Red1"}, {"teamNumber": 254, "station": "Red2"},
+			{"teamNumber": 971, "station": "Red3"}, {"teamNumber": 1, "station": "Blue1"},
+			{"teamNumber": 2, "station": "Blue2"}, {"teamNumber": 3, "station": "Blue3"}]},
+		{"matchNumber": 2, "scoreRedFinal": null, "scoreBlueFinal": null, "teams": [
+			{"teamNumber": 3, "station": "Blue3"}, {"teamNumber": 2, "station": "Blue2"},
+			{"teamNumber": 1, "station": "Blue1"}, {"teamNumber": 971, "station": "Red3"},
+			{"teamNumber": 254, "station": "Red2"}, {"teamNumber": 4476, "station": "Red1"},
+			{"teamNumber": 9999, "station": "Surrogate"}]}
+	]}`)
+	api.set("/v2.0/2018/schedule/CASJ/playoff/hybrid", `{"Schedule": [
+		{"matchNumber": 1, "scoreRedFinal": 0, "scoreBlueFinal": 0, "teams": [
+			{"teamNumber": 7, "station": "Red1"}, {"teamNumber": 8, "station": "Red2"},
+			{"teamNumber": 9, "station": "Red3"}, {"teamNumber": 10, "station": "Blue1"},
+			{"teamNumber": 11, "station": "Blue2"}, {"teamNumber": 12, "station": "Blue3"}]}
+	]}`)
+	return api, NewFRCEvents(server.URL+"/v2.0/", "scout", "the authorization key")
+}
+
+func TestFRCEventsEvent(t *testing.T) {
+	_, frc := newFakeFRCEvents(t)
+	comp, err := frc.Event("2018casj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comp.Key != "2018casj" || comp.Name != "Silicon Valley Regional" || comp.Location != "San Jose, CA, USA" ||
+		comp.Start.String() != "2018-03-28 00:00:00" || comp.End.String() != "2018-03-31 00:00:00" {
+		t.Errorf("Event = %+v", comp)
+	}
+	teams, err := frc.EventTeams("2018casj")
+	if err != nil || !reflect.DeepEqual(teams, []int{254, 971, 4476}) {
+		t.Errorf("EventTeams = %v, %v", teams, err)
+	}
+
+	if _, err = frc.Event("casj"); err != ErrCompetitionNotFound {
+		t.Errorf("Event of a key without a season = %v, want ErrCompetitionNotFound", err)
+	}
+	if _, err = frc.Event("2017casj"); err != ErrCompetitionNotFound {
+		t.Errorf("Event of an unknown competition = %v, want ErrCompetitionNotFound", err)
+	}
+	if _, err = NewFRCEvents(frc.baseURL, "scout", "wrong").Event("2018casj"); err != ErrSourceAccessDenied {
+		t.Errorf("Event with the wrong key = %v, want ErrSourceAccessDenied", err)
+	}
+}
+
+func TestFRCEventsEventMatches(t *testing.T) {
+	api, frc := newFakeFRCEvents(t)
+	want := []Match{
+		{Level: Qualification, Number: 1, Red: []int{4476, 254, 971}, Blue: []int{1, 2, 3},
+			Scored: true, RedScore: 310, BlueScore: 120},
+		{Level: Qualification, Number: 2, Red: []int{4476, 254, 971}, Blue: []int{1, 2, 3}},
+		// a shutout is still a score
+		{Level: Playoff, Number: 1, Red: []int{7, 8, 9}, Blue: []int{10, 11, 12}, Scored: true},
+	}
+	for i := 0; i < 2; i++ {
+		matches, err := frc.EventMatches("2018casj")
+		if err != nil || !reflect.DeepEqual(matches, want) {
+			t.Errorf("EventMatches =\n%+v, %v\nwant\n%+v", matches, err, want)
+		}
+	}
+	// the second time both schedules are answered from the cache
+	if requests, notModified := api.counts(); requests != 4 || notModified != 2 {
+		t.Errorf("the API answered %d requests, %d of them not modified; want 4 and 2", requests, notModified)
+	}
+}
+
+func TestImportCompetitionYear(t *testing.T) {
+	_, frc := newFakeFRCEvents(t)
+	db := newTestDB(t)
+	db.year = testYear + 1
+	if err := db.ImportCompetition(frc, "2018casj"); err != ErrCompetitionYear {
+		t.Errorf("importing a %d competition into the %d database = %v, want ErrCompetitionYear", testYear, db.year, err)
+	}
+
+	db.year = testYear
+	if err := db.ImportCompetition(frc, "2018casj"); err != nil {
+		t.Fatal(err)
+	}
+	comp, err := db.GetCompetition("2018casj")
+	if err != nil || comp.Source != "frc" || !reflect.DeepEqual(comp.Teams, []int{254, 971, 4476}) {
+		t.Errorf("GetCompetition after importing = %+v, %v", comp, err)
+	}
+}
@@ -0,0 +1,265 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// MatchLevel separates qualification matches from playoff matches, since each
+// level numbers its matches from one.
+type MatchLevel int
+
+const (
+	// Qualification matches are the ones played before alliance selection
+	Qualification MatchLevel = iota
+	// Playoff matches are the ones played after alliance selection
+	Playoff
+)
+
+// String returns the name of the level as it is stored in the database
+func (level MatchLevel) String() string {
+	if level == Playoff {
+		return "playoff"
+	}
+	return "qual"
+}
+
+// Abbreviation returns the single letter used before match numbers, such as
+// the Q in Q12
+func (level MatchLevel) Abbreviation() string {
+	if level == Playoff {
+		return "P"
+	}
+	return "Q"
+}
+
+// ParseMatchLevel converts the name of a level back into a MatchLevel.
+// Returns ErrInvalidMatch if the name isn't recognized.
+func ParseMatchLevel(name string) (MatchLevel, error) {
+	switch name {
+	case "qual":
+		return Qualification, nil
+	case "playoff":
+		return Playoff, nil
+	}
+	return Qualification, ErrInvalidMatch
+}
+
+// Match represents a single scheduled match along with its official result,
+// if it's known.
+type Match struct {
+	Id          int64
+	Competition string
+	Level       MatchLevel
+	Number      int
+	Red         []int
+	Blue        []int
+
+	// Scored is true once the official scores have been recorded
+	Scored    bool
+	RedScore  int
+	BlueScore int
+
+	// Scouted maps each team in the match to the number of submissions made
+	// about it.  Only filled in by GetMatches.
+	Scouted map[int]int
+}
+
+// Teams returns every team scheduled for the match, red alliance first
+func (match Match) Teams() []int {
+	return append(append([]int{}, match.Red...), match.Blue...)
+}
+
+// AllianceOf finds the alliance a team played on.  Returns false if the team
+// wasn't in the match.
+func (match Match) AllianceOf(team int) (Alliance, bool) {
+	for _, t := range match.Red {
+		if t == team {
+			return Red, true
+		}
+	}
+	for _, t := range match.Blue {
+		if t == team {
+			return Blue, true
+		}
+	}
+	return Red, false
+}
+
+// Coverage counts how many of the teams in the match have been scouted
+func (match Match) Coverage() int {
+	count := 0
+	for _, team := range match.Teams() {
+		if match.Scouted[team] > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Record is the number of matches a team has won, lost and tied
+type Record struct {
+	Wins   int
+	Losses int
+	Ties   int
+}
+
+// String formats the record as wins-losses-ties
+func (record Record) String() string {
+	return fmt.Sprintf("%d-%d-%d", record.Wins, record.Losses, record.Ties)
+}
+
+// RecordOf tallies the results of every scored match the team played in
+func RecordOf(team int, matches []Match) Record {
+	var record Record
+	for _, match := range matches {
+		alliance, ok := match.AllianceOf(team)
+		if !ok || !match.Scored {
+			continue
+		}
+		ours, theirs := match.RedScore, match.BlueScore
+		if alliance == Blue {
+			ours, theirs = theirs, ours
+		}
+		switch {
+		case ours > theirs:
+			record.Wins++
+		case ours < theirs:
+			record.Losses++
+		default:
+			record.Ties++
+		}
+	}
+	return record
+}
+
+// GetMatches retrieves every match of a competition in the order they are
+// played, along with how many submissions were made about each robot in
+// them.  If competition is empty, the matches of every competition are
+// retrieved.
+func (db DB) GetMatches(competition string) ([]Match, error) {
+	query := `SELECT id, competition, level, match_number, red1, red2, red3, blue1, blue2, blue3, red_score, blue_score
+ FROM matches`
+	args := []interface{}{}
+	if competition != "" {
+		query += " WHERE competition=?"
+		args = append(args, competition)
+	}
+	query += " ORDER BY competition, level DESC, match_number" // 'qual' sorts after 'playoff'
+
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.GetMatches: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []Match{}
+	indices := map[string]int{}
+	for rows.Next() {
+		var (
+			match               Match
+			level               string
+			red, blue           [3]int
+			redScore, blueScore sql.NullInt64
+		)
+		err = rows.Scan(&match.Id, &match.Competition, &level, &match.Number,
+			&red[0], &red[1], &red[2], &blue[0], &blue[1], &blue[2], &redScore, &blueScore)
+		if err != nil {
+			return nil, err
+		}
+		match.Level, _ = ParseMatchLevel(level)
+		match.Red = red[:]
+		match.Blue = blue[:]
+		match.Scored = redScore.Valid && blueScore.Valid
+		match.RedScore = int(redScore.Int64)
+		match.BlueScore = int(blueScore.Int64)
+		match.Scouted = map[int]int{}
+		indices[matchKey(match.Competition, match.Level, match.Number)] = len(matches)
+		matches = append(matches, match)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, db.fillMatchCoverage(competition, matches, indices)
+}
+
+// fillMatchCoverage counts the submissions made about every robot in the
+// matches.  indices maps the key of each match to its position in matches.
+func (db DB) fillMatchCoverage(competition string, matches []Match, indices map[string]int) error {
+	query := `SELECT competition, level, match_number, team, COUNT(*) FROM submissions`
+	args := []interface{}{}
+	if competition != "" {
+		query += " WHERE competition=?"
+		args = append(args, competition)
+	}
+	query += " GROUP BY competition, level, match_number, team"
+
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			comp, level         string
+			number, team, count int
+		)
+		if err = rows.Scan(&comp, &level, &number, &team, &count); err != nil {
+			return err
+		}
+		matchLevel, _ := ParseMatchLevel(level)
+		if i, ok := indices[matchKey(comp, matchLevel, number)]; ok {
+			matches[i].Scouted[team] = count
+		}
+	}
+	return rows.Err()
+}
+
+func matchKey(competition string, level MatchLevel, number int) string {
+	return fmt.Sprintf("%s/%s%d", competition, level.Abbreviation(), number)
+}
+
+// InsertMatch adds a match to the schedule.  Official scores are stored along
+// with it if they are known.
+func (db DB) InsertMatch(match Match) error {
+	if match.Competition == "" {
+		return ErrInvalidCompetition
+	}
+	if match.Number <= 0 || len(match.Red) != 3 || len(match.Blue) != 3 {
+		return ErrInvalidMatch
+	}
+
+	var redScore, blueScore sql.NullInt64
+	if match.Scored {
+		redScore = sql.NullInt64{Int64: int64(match.RedScore), Valid: true}
+		blueScore = sql.NullInt64{Int64: int64(match.BlueScore), Valid: true}
+	}
+	_, err := db.db.Exec(`INSERT INTO matches
+ (competition, level, match_number, red1, red2, red3, blue1, blue2, blue3, red_score, blue_score)
+ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		match.Competition, match.Level.String(), match.Number,
+		match.Red[0], match.Red[1], match.Red[2], match.Blue[0], match.Blue[1], match.Blue[2],
+		redScore, blueScore)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.InsertMatch: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
+
+// SetMatchScore records the official scores of a match that's already in the
+// schedule.
+func (db DB) SetMatchScore(competition string, level MatchLevel, number, redScore, blueScore int) error {
+	_, err := db.db.Exec(`UPDATE matches SET red_score=?, blue_score=?
+ WHERE competition=? AND level=? AND match_number=?`,
+		redScore, blueScore, competition, level.String(), number)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.SetMatchScore: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMatchLevel(t *testing.T) {
+	for _, level := range []MatchLevel{Qualification, Playoff} {
+		if parsed, err := ParseMatchLevel(level.String()); err != nil || parsed != level {
+			t.Errorf("ParseMatchLevel(%q) = %v, %v", level.String(), parsed, err)
+		}
+	}
+	if _, err := ParseMatchLevel("final"); err != ErrInvalidMatch {
+		t.Errorf("ParseMatchLevel(\"final\") = %v, want ErrInvalidMatch", err)
+	}
+}
+
+func TestRecordOf(t *testing.T) {
+	matches := []Match{
+		{Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}, Scored: true, RedScore: 100, BlueScore: 90},
+		{Red: []int{4, 5, 6}, Blue: []int{1, 7, 8}, Scored: true, RedScore: 100, BlueScore: 90},
+		{Red: []int{7, 8, 9}, Blue: []int{1, 2, 3}, Scored: true, RedScore: 50, BlueScore: 50},
+		{Red: []int{7, 8, 9}, Blue: []int{1, 2, 3}, Scored: true, RedScore: 10, BlueScore: 60},
+		{Red: []int{1, 8, 9}, Blue: []int{4, 2, 3}}, // not played yet
+	}
+	tests := []struct {
+		team int
+		want Record
+	}{
+		{1, Record{Wins: 2, Losses: 1, Ties: 1}},
+		{4, Record{Wins: 1, Losses: 1}},
+		{9, Record{Losses: 1, Ties: 1}},
+		{10, Record{}},
+	}
+	for _, test := range tests {
+		if record := RecordOf(test.team, matches); record != test.want {
+			t.Errorf("RecordOf(%d) = %v, want %v", test.team, record, test.want)
+		}
+	}
+	if s := (Record{Wins: 3, Losses: 2, Ties: 1}).String(); s != "3-2-1" {
+		t.Errorf("Record.String() = %q", s)
+	}
+}
+
+func TestInsertMatch(t *testing.T) {
+	db := newTestDB(t)
+	addTestCompetition(t, db)
+	for _, match := range []Match{
+		{Competition: "2018casj", Level: Playoff, Number: 1, Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}},
+		{Competition: "2018casj", Number: 2, Red: []int{7, 8, 9}, Blue: []int{1, 2, 3}},
+		{Competition: "2018casj", Number: 1, Red: []int{4476, 254, 971}, Blue: []int{4, 5, 6},
+			Scored: true, RedScore: 310, BlueScore: 120},
+	} {
+		if err := db.InsertMatch(match); err != nil {
+			t.Fatal(err)
+		}
+	}
+	invalid := []struct {
+		match Match
+		want  error
+	}{
+		{Match{Number: 3, Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}}, ErrInvalidCompetition},
+		{Match{Competition: "2018casj", Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}}, ErrInvalidMatch},
+		{Match{Competition: "2018casj", Number: 3, Red: []int{1, 2}, Blue: []int{4, 5, 6}}, ErrInvalidMatch},
+		{Match{Competition: "2018casj", Number: 2, Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}}, ErrDatabaseUpdate},
+	}
+	for _, test := range invalid {
+		if err := db.InsertMatch(test.match); err != test.want {
+			t.Errorf("InsertMatch(%+v) = %v, want %v", test.match, err, test.want)
+		}
+	}
+
+	if err := db.SetMatchScore("2018casj", Qualification, 2, 80, 95); err != nil {
+		t.Fatal(err)
+	}
+	sub := testSubmission()
+	sub.Level, sub.Match, sub.Team = Qualification, 1, 4476
+	for i := 0; i < 2; i++ {
+		sub.ClientId = ""
+		if err := db.InsertSubmission(&sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// qualifications come before playoffs
+	matches, err := db.GetMatches("2018casj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("GetMatches found %d matches", len(matches))
+	}
+	first, second, playoff := matches[0], matches[1], matches[2]
+	if first.Level != Qualification || first.Number != 1 || !first.Scored || first.RedScore != 310 ||
+		first.BlueScore != 120 || !reflect.DeepEqual(first.Red, []int{4476, 254, 971}) ||
+		first.Coverage() != 1 || first.Scouted[4476] != 2 {
+		t.Errorf("the first match is %+v", first)
+	}
+	if second.Number != 2 || !second.Scored || second.RedScore != 80 || second.BlueScore != 95 {
+		t.Errorf("the second match is %+v", second)
+	}
+	if playoff.Level != Playoff || playoff.Number != 1 || playoff.Scored || playoff.Coverage() != 0 {
+		t.Errorf("the playoff match is %+v", playoff)
+	}
+	if alliance, ok := first.AllianceOf(254); !ok || alliance != Red {
+		t.Errorf("team 254 played on %v, %v", alliance, ok)
+	}
+	if alliance, ok := first.AllianceOf(5); !ok || alliance != Blue {
+		t.Errorf("team 5 played on %v, %v", alliance, ok)
+	}
+	if _, ok := first.AllianceOf(9); ok {
+		t.Error("team 9 was found in a match it didn't play")
+	}
+
+	if all, err := db.GetMatches(""); err != nil || len(all) != 3 {
+		t.Errorf("GetMatches of every competition = %d matches, %v", len(all), err)
+	}
+	if none, err := db.GetMatches("2018cada"); err != nil || len(none) != 0 {
+		t.Errorf("GetMatches of an unknown competition = %+v, %v", none, err)
+	}
+}
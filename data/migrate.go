@@ -0,0 +1,203 @@
+package data
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationFiles holds the migrations of every dialect of SQL, one directory
+// each, in files named after their version and what they do, such as
+// 0002_add_sessions.sql.  Migrations are never edited once released; changes
+// to the schema are made by adding another.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// schemaVersionTable records which migrations a database has had.  It's
+// created the same way in every dialect.
+const schemaVersionTable = `CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied DATETIME NOT NULL
+)`
+
+// Migration is one step in building the schema of a year's database
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrations lists every migration of a dialect of SQL, such as "mysql", in
+// the order they're applied
+func Migrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("data: no migrations for %s", dialect)
+	}
+	migrations := []Migration{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		parts := strings.SplitN(name, "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 || !strings.HasSuffix(entry.Name(), ".sql") {
+			return nil, fmt.Errorf("data: misnamed migration %s", entry.Name())
+		}
+		contents, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: parts[1], SQL: string(contents)})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Statements splits the SQL of a migration into the statements it's made of.
+// Statements end with a semicolon at the end of a line, and lines starting
+// with -- are comments.
+func (migration Migration) Statements() []string {
+	statements := []string{}
+	statement := ""
+	for _, line := range strings.Split(migration.SQL, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") || (trimmed == "" && statement == "") {
+			continue
+		}
+		statement += line + "\n"
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(statement))
+			statement = ""
+		}
+	}
+	if strings.TrimSpace(statement) != "" {
+		statements = append(statements, strings.TrimSpace(statement))
+	}
+	return statements
+}
+
+// Migrate brings the database of a year up to date with the backend in use,
+// creating the database first if it doesn't exist yet.  Returns the
+// migrations that were applied.  With dryRun, nothing at all is changed and
+// the migrations that would have been applied are returned instead.
+func Migrate(year int, dryRun bool) ([]Migration, error) {
+	if !VerifyYear(year) {
+		return nil, ErrWrongYear
+	}
+	if !dryRun {
+		if err := backend.Provision(year); err != nil {
+			return nil, err
+		}
+	}
+	db, err := backend.Open(year)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return migrate(db, backend.Dialect(), dryRun)
+}
+
+// migrate applies every migration of the dialect the database hasn't had yet,
+// each in a transaction of its own along with the record that it was applied.
+// MySQL commits schema changes as soon as they're made, so a migration that
+// fails there may be left half applied; every migration creates what it needs
+// only if it doesn't exist for that reason.
+func migrate(db *sql.DB, dialect string, dryRun bool) ([]Migration, error) {
+	migrations, err := Migrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[int]bool{}
+	rows, err := db.Query(`SELECT version FROM schema_version`)
+	if err == nil {
+		for rows.Next() {
+			var version int
+			if err = rows.Scan(&version); err != nil {
+				break
+			}
+			applied[version] = true
+		}
+		if err == nil {
+			err = rows.Err()
+		}
+		rows.Close()
+	}
+	if err != nil && !dryRun {
+		// the table may simply not exist yet
+		if _, err = db.Exec(schemaVersionTable); err != nil {
+			return nil, err
+		}
+	}
+
+	pending := []Migration{}
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	if dryRun {
+		return pending, nil
+	}
+
+	for i, migration := range pending {
+		if err = applyMigration(db, migration); err != nil {
+			fmt.Fprintf(os.Stderr, "data.migrate: %04d_%s: %s\n", migration.Version, migration.Name, err.Error())
+			return pending[:i], ErrDatabaseUpdate
+		}
+	}
+	return pending, nil
+}
+
+// addColumnStatement matches statements adding a column to a table, giving
+// the names of the table and the column
+var addColumnStatement = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(\w+)\s+ADD\s+COLUMN\s+(\w+)\b`)
+
+// applyMigration runs the statements of a migration.  Databases from before
+// there were migrations were set up by hand with whichever columns the
+// scouting system needed at the time, so a statement adding a column the
+// table already has is skipped rather than failing, which neither MySQL nor
+// SQLite can be asked to do.
+func applyMigration(db *sql.DB, migration Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	for _, statement := range migration.Statements() {
+		if match := addColumnStatement.FindStringSubmatch(statement); match != nil && hasColumn(tx, match[1], match[2]) {
+			continue
+		}
+		if _, err = tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`INSERT INTO schema_version (version, name, applied) VALUES (?, ?, ?)`,
+		migration.Version, migration.Name, Now())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// hasColumn reports whether a table has a column, by asking for it without
+// asking for any rows
+func hasColumn(tx *sql.Tx, table, column string) bool {
+	rows, err := tx.Query("SELECT " + column + " FROM " + table + " WHERE 1=0")
+	if err != nil {
+		return false
+	}
+	rows.Close()
+	return true
+}
@@ -0,0 +1,159 @@
+package data
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// baselineSchema is the schema deployed databases were set up with by hand
+// before there were migrations, with only the columns needed at the time
+const baselineSchema = `
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL UNIQUE,
+	realname TEXT NOT NULL,
+	passhash BLOB NOT NULL,
+	authid INTEGER NOT NULL DEFAULT -1,
+	lastseen DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	admin BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE TABLE competitions (
+	event_key TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	location TEXT NOT NULL DEFAULT '',
+	start_date DATETIME NOT NULL,
+	end_date DATETIME NOT NULL
+);
+CREATE TABLE submissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	match_number INTEGER NOT NULL,
+	team INTEGER NOT NULL,
+	alliance TEXT NOT NULL,
+	scout INTEGER NOT NULL,
+	created DATETIME NOT NULL
+);
+CREATE TABLE submission_values (
+	submission INTEGER NOT NULL,
+	field TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (submission, field)
+);
+`
+
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	db := openSQLite(t)
+	if _, err := db.Exec(baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+	passhash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO users (username, realname, passhash, authid, admin) VALUES ('old', 'Old User', ?, 5, TRUE)`, passhash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO submissions (match_number, team, alliance, scout, created) VALUES (3, 4476, 'red', 1, ?)`, Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := Migrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := migrate(db, "sqlite", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("applied %d migrations to the baseline, want %d", len(applied), len(migrations))
+	}
+	if applied, err = migrate(db, "sqlite", false); err != nil || len(applied) != 0 {
+		t.Fatalf("migrating again applied %d migrations, err %v", len(applied), err)
+	}
+
+	var (
+		level, competition string
+		strategist         bool
+		team               int
+	)
+	err = db.QueryRow(`SELECT level, competition FROM submissions`).Scan(&level, &competition)
+	if err != nil || level != "qual" || competition != "" {
+		t.Errorf("old submission has level %q, competition %q, err %v", level, competition, err)
+	}
+	err = db.QueryRow(`SELECT team, strategist FROM users`).Scan(&team, &strategist)
+	if err != nil || team != 0 || strategist {
+		t.Errorf("old user has team %d, strategist %v, err %v", team, strategist, err)
+	}
+
+	scouting := DB{db: db, year: 2018}
+	cookie, err := scouting.Login("old", "correct horse", "laptop")
+	if err != nil {
+		t.Fatalf("logging in after migrating: %v", err)
+	}
+	request := httptest.NewRequest("GET", "/", nil)
+	request.AddCookie(cookie)
+	if user := scouting.GetUser(request); user == nil || user.Username != "old" || !user.Admin {
+		t.Errorf("GetUser after migrating = %+v", user)
+	}
+	if _, err = scouting.CreateUser("new", "New User", "another password", 4476, "old", "correct horse", "tablet"); err != nil {
+		t.Errorf("creating a user after migrating: %v", err)
+	}
+}
+
+func TestMigrateFresh(t *testing.T) {
+	db := openSQLite(t)
+	applied, err := migrate(db, "sqlite", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions int
+	if err = db.QueryRow(`SELECT COUNT(*) FROM schema_version`).Scan(&versions); err != nil || versions != len(applied) {
+		t.Errorf("schema_version has %d rows, want %d (err %v)", versions, len(applied), err)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	db := openSQLite(t)
+	pending, err := migrate(db, "sqlite", true)
+	if err != nil || len(pending) == 0 {
+		t.Fatalf("dry run on an empty database found %d migrations, err %v", len(pending), err)
+	}
+	if _, err = db.Exec(`SELECT id FROM users`); err == nil {
+		t.Error("dry run created tables")
+	}
+}
+
+func TestMigrationStatements(t *testing.T) {
+	for _, dialect := range []string{"mysql", "sqlite"} {
+		migrations, err := Migrations(dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, migration := range migrations {
+			if migration.Version != i+1 {
+				t.Errorf("%s migration %s has version %d, want %d", dialect, migration.Name, migration.Version, i+1)
+			}
+			for _, statement := range migration.Statements() {
+				if statement[len(statement)-1] != ';' {
+					t.Errorf("%s migration %s has an unterminated statement %q", dialect, migration.Name, statement)
+				}
+			}
+		}
+	}
+}
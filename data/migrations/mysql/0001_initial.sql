@@ -0,0 +1,142 @@
+-- the tables of a year's database as of the first version with migrations
+
+CREATE TABLE IF NOT EXISTS users (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	username VARCHAR(64) NOT NULL UNIQUE,
+	realname VARCHAR(128) NOT NULL,
+	team INT NOT NULL DEFAULT 0,
+	passhash VARBINARY(72) NOT NULL,
+	authid BIGINT NOT NULL DEFAULT -1,
+	lastseen DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	admin BOOLEAN NOT NULL DEFAULT FALSE,
+	strategist BOOLEAN NOT NULL DEFAULT FALSE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS competitions (
+	event_key VARCHAR(32) NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	location VARCHAR(255) NOT NULL DEFAULT '',
+	start_date DATETIME NOT NULL,
+	end_date DATETIME NOT NULL,
+	source VARCHAR(16) NOT NULL DEFAULT ''
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS competition_teams (
+	competition VARCHAR(32) NOT NULL,
+	team INT NOT NULL,
+	PRIMARY KEY (competition, team)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS matches (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	competition VARCHAR(32) NOT NULL,
+	level VARCHAR(16) NOT NULL,
+	match_number INT NOT NULL,
+	red1 INT NOT NULL,
+	red2 INT NOT NULL,
+	red3 INT NOT NULL,
+	blue1 INT NOT NULL,
+	blue2 INT NOT NULL,
+	blue3 INT NOT NULL,
+	red_score INT,
+	blue_score INT,
+	UNIQUE (competition, level, match_number)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS submissions (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	competition VARCHAR(32) NOT NULL,
+	level VARCHAR(16) NOT NULL,
+	match_number INT NOT NULL,
+	team INT NOT NULL,
+	alliance VARCHAR(8) NOT NULL,
+	scout BIGINT NOT NULL,
+	created DATETIME NOT NULL,
+	client_id VARCHAR(64) UNIQUE,
+	INDEX (competition, level, match_number)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS submission_values (
+	submission BIGINT NOT NULL,
+	field VARCHAR(64) NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (submission, field)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS submission_edits (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	submission BIGINT NOT NULL,
+	editor BIGINT NOT NULL,
+	edited DATETIME NOT NULL,
+	previous TEXT NOT NULL,
+	INDEX (submission)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS pit_records (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	competition VARCHAR(32) NOT NULL,
+	team INT NOT NULL,
+	scout BIGINT NOT NULL,
+	updated DATETIME NOT NULL,
+	UNIQUE (competition, team)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS pit_values (
+	record BIGINT NOT NULL,
+	field VARCHAR(64) NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (record, field)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS pit_revisions (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	record BIGINT NOT NULL,
+	scout BIGINT NOT NULL,
+	updated DATETIME NOT NULL,
+	previous TEXT NOT NULL,
+	INDEX (record)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS pick_lists (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	competition VARCHAR(32) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	creator BIGINT NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS pick_list_entries (
+	list BIGINT NOT NULL,
+	team INT NOT NULL,
+	position INT NOT NULL,
+	note TEXT NOT NULL,
+	do_not_pick BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (list, team)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS alliance_picks (
+	competition VARCHAR(32) NOT NULL,
+	team INT NOT NULL,
+	picked BOOLEAN NOT NULL,
+	PRIMARY KEY (competition, team)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS photos (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	team INT NOT NULL,
+	competition VARCHAR(32) NOT NULL,
+	uploader BIGINT NOT NULL,
+	uploaded DATETIME NOT NULL,
+	content_type VARCHAR(32) NOT NULL,
+	INDEX (team)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS scout_assignments (
+	competition VARCHAR(32) NOT NULL,
+	level VARCHAR(16) NOT NULL,
+	match_number INT NOT NULL,
+	position INT NOT NULL,
+	team INT NOT NULL,
+	scout BIGINT NOT NULL,
+	PRIMARY KEY (competition, level, match_number, position),
+	INDEX (scout)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
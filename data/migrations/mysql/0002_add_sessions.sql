@@ -0,0 +1,13 @@
+-- every login gets a session of its own, so a user may be logged in on more
+-- than one device; users.authid is no longer used
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	user_id BIGINT NOT NULL,
+	device VARCHAR(128) NOT NULL DEFAULT '',
+	created DATETIME NOT NULL,
+	lastseen DATETIME NOT NULL,
+	expires DATETIME NOT NULL,
+	INDEX (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
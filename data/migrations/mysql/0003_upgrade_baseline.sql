@@ -0,0 +1,18 @@
+-- databases set up by hand before there were migrations were only created
+-- with the columns needed at the time, so add every column that has been
+-- added since to the tables they already had; columns a table has already
+-- are left alone
+
+ALTER TABLE users ADD COLUMN team INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN strategist BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE competitions ADD COLUMN source VARCHAR(16) NOT NULL DEFAULT '';
+
+ALTER TABLE submissions ADD COLUMN competition VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE submissions ADD COLUMN level VARCHAR(16) NOT NULL DEFAULT 'qual';
+ALTER TABLE submissions ADD COLUMN client_id VARCHAR(64) UNIQUE;
+
+-- sessions took over from these, so new users are created without them
+ALTER TABLE users MODIFY authid BIGINT NOT NULL DEFAULT -1;
+ALTER TABLE users MODIFY lastseen DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
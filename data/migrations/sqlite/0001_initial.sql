@@ -0,0 +1,137 @@
+-- the tables of a year's database as of the first version with migrations
+
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL UNIQUE,
+	realname TEXT NOT NULL,
+	team INTEGER NOT NULL DEFAULT 0,
+	passhash BLOB NOT NULL,
+	authid INTEGER NOT NULL DEFAULT -1,
+	lastseen DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	admin BOOLEAN NOT NULL DEFAULT FALSE,
+	strategist BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS competitions (
+	event_key TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	location TEXT NOT NULL DEFAULT '',
+	start_date DATETIME NOT NULL,
+	end_date DATETIME NOT NULL,
+	source TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS competition_teams (
+	competition TEXT NOT NULL,
+	team INTEGER NOT NULL,
+	PRIMARY KEY (competition, team)
+);
+
+CREATE TABLE IF NOT EXISTS matches (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	competition TEXT NOT NULL,
+	level TEXT NOT NULL,
+	match_number INTEGER NOT NULL,
+	red1 INTEGER NOT NULL,
+	red2 INTEGER NOT NULL,
+	red3 INTEGER NOT NULL,
+	blue1 INTEGER NOT NULL,
+	blue2 INTEGER NOT NULL,
+	blue3 INTEGER NOT NULL,
+	red_score INTEGER,
+	blue_score INTEGER,
+	UNIQUE (competition, level, match_number)
+);
+
+CREATE TABLE IF NOT EXISTS submissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	competition TEXT NOT NULL,
+	level TEXT NOT NULL,
+	match_number INTEGER NOT NULL,
+	team INTEGER NOT NULL,
+	alliance TEXT NOT NULL,
+	scout INTEGER NOT NULL,
+	created DATETIME NOT NULL,
+	client_id TEXT UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS submission_values (
+	submission INTEGER NOT NULL,
+	field TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (submission, field)
+);
+
+CREATE TABLE IF NOT EXISTS submission_edits (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	submission INTEGER NOT NULL,
+	editor INTEGER NOT NULL,
+	edited DATETIME NOT NULL,
+	previous TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pit_records (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	competition TEXT NOT NULL,
+	team INTEGER NOT NULL,
+	scout INTEGER NOT NULL,
+	updated DATETIME NOT NULL,
+	UNIQUE (competition, team)
+);
+
+CREATE TABLE IF NOT EXISTS pit_values (
+	record INTEGER NOT NULL,
+	field TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (record, field)
+);
+
+CREATE TABLE IF NOT EXISTS pit_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	record INTEGER NOT NULL,
+	scout INTEGER NOT NULL,
+	updated DATETIME NOT NULL,
+	previous TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pick_lists (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	competition TEXT NOT NULL,
+	name TEXT NOT NULL,
+	creator INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pick_list_entries (
+	list INTEGER NOT NULL,
+	team INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	note TEXT NOT NULL DEFAULT '',
+	do_not_pick BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (list, team)
+);
+
+CREATE TABLE IF NOT EXISTS alliance_picks (
+	competition TEXT NOT NULL,
+	team INTEGER NOT NULL,
+	picked BOOLEAN NOT NULL,
+	PRIMARY KEY (competition, team)
+);
+
+CREATE TABLE IF NOT EXISTS photos (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	team INTEGER NOT NULL,
+	competition TEXT NOT NULL,
+	uploader INTEGER NOT NULL,
+	uploaded DATETIME NOT NULL,
+	content_type TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS scout_assignments (
+	competition TEXT NOT NULL,
+	level TEXT NOT NULL,
+	match_number INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	team INTEGER NOT NULL,
+	scout INTEGER NOT NULL,
+	PRIMARY KEY (competition, level, match_number, position)
+);
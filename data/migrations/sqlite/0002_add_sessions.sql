@@ -0,0 +1,14 @@
+-- every login gets a session of its own, so a user may be logged in on more
+-- than one device; users.authid is no longer used
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	token_hash TEXT NOT NULL UNIQUE,
+	user_id INTEGER NOT NULL,
+	device TEXT NOT NULL DEFAULT '',
+	created DATETIME NOT NULL,
+	lastseen DATETIME NOT NULL,
+	expires DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id ON sessions (user_id);
@@ -0,0 +1,15 @@
+-- databases set up by hand before there were migrations were only created
+-- with the columns needed at the time, so add every column that has been
+-- added since to the tables they already had; columns a table has already
+-- are left alone
+
+ALTER TABLE users ADD COLUMN team INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN strategist BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE competitions ADD COLUMN source TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE submissions ADD COLUMN competition TEXT NOT NULL DEFAULT '';
+ALTER TABLE submissions ADD COLUMN level TEXT NOT NULL DEFAULT 'qual';
+ALTER TABLE submissions ADD COLUMN client_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS submissions_client_id ON submissions (client_id);
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"math"
+	"sort"
+)
+
+// ridge is added to the diagonal of the normal equations so that they can
+// still be solved early in a competition, before every team has played
+// enough matches to separate its contribution from its partners'.
+const ridge = 1e-6
+
+// PowerRating estimates how much a single team contributes to the scores of
+// the matches it plays in.
+type PowerRating struct {
+	Team int
+
+	// OPR is the Offensive Power Rating: the team's share of its alliance's
+	// score.
+	OPR float64
+	// DPR is the Defensive Power Rating: the team's share of the opposing
+	// alliance's score.  Lower is better.
+	DPR float64
+	// CCWM is the Calculated Contribution to Winning Margin, which is the
+	// same as OPR - DPR.
+	CCWM float64
+}
+
+// ComputePowerRatings fits the OPR, DPR and CCWM of every team that played a
+// scored qualification match.  Every alliance's score is modeled as the sum of
+// its teams' ratings, and the ratings are found by least squares.  The
+// ratings are ordered by OPR, best first.
+func ComputePowerRatings(matches []Match) []PowerRating {
+	indices := map[int]int{}
+	teams := []int{}
+	for _, match := range matches {
+		if !match.Scored || match.Level != Qualification {
+			continue
+		}
+		for _, team := range match.Teams() {
+			if _, ok := indices[team]; !ok {
+				indices[team] = len(teams)
+				teams = append(teams, team)
+			}
+		}
+	}
+	if len(teams) == 0 {
+		return []PowerRating{}
+	}
+
+	// build the normal equations directly, since every row of the alliance
+	// matrix is a handful of ones
+	n := len(teams)
+	normal := make([][]float64, n)
+	for i := range normal {
+		normal[i] = make([]float64, n)
+		normal[i][i] = ridge
+	}
+	scored := make([]float64, n)
+	allowed := make([]float64, n)
+	addAlliance := func(alliance []int, score, opposing int) {
+		for _, a := range alliance {
+			for _, b := range alliance {
+				normal[indices[a]][indices[b]]++
+			}
+			scored[indices[a]] += float64(score)
+			allowed[indices[a]] += float64(opposing)
+		}
+	}
+	for _, match := range matches {
+		if !match.Scored || match.Level != Qualification {
+			continue
+		}
+		addAlliance(match.Red, match.RedScore, match.BlueScore)
+		addAlliance(match.Blue, match.BlueScore, match.RedScore)
+	}
+
+	solution := solve(normal, scored, allowed)
+	ratings := make([]PowerRating, n)
+	for i, team := range teams {
+		ratings[i] = PowerRating{
+			Team: team,
+			OPR:  solution[0][i],
+			DPR:  solution[1][i],
+			CCWM: solution[0][i] - solution[1][i],
+		}
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		return ratings[i].OPR > ratings[j].OPR
+	})
+	return ratings
+}
+
+// GetPowerRatings computes the power ratings of every team at a competition
+// from the official match results.
+func (db DB) GetPowerRatings(competition string) ([]PowerRating, error) {
+	matches, err := db.GetMatches(competition)
+	if err != nil {
+		return nil, err
+	}
+	return ComputePowerRatings(matches), nil
+}
+
+// solve finds x in a*x = b for every right hand side b using Gaussian
+// elimination with partial pivoting.  a must be square and is modified.
+func solve(a [][]float64, rhs ...[]float64) [][]float64 {
+	n := len(a)
+	b := make([][]float64, len(rhs))
+	for k := range rhs {
+		b[k] = append([]float64{}, rhs[k]...)
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for k := range b {
+			b[k][col], b[k][pivot] = b[k][pivot], b[k][col]
+		}
+		if a[col][col] == 0 {
+			continue // the column is entirely zero, so leave its value at zero
+		}
+
+		for row := col + 1; row < n; row++ {
+			factor := a[row][col] / a[col][col]
+			if factor == 0 {
+				continue
+			}
+			for c := col; c < n; c++ {
+				a[row][c] -= factor * a[col][c]
+			}
+			for k := range b {
+				b[k][row] -= factor * b[k][col]
+			}
+		}
+	}
+
+	x := make([][]float64, len(b))
+	for k := range b {
+		x[k] = make([]float64, n)
+		for row := n - 1; row >= 0; row-- {
+			if a[row][row] == 0 {
+				continue
+			}
+			sum := b[k][row]
+			for c := row + 1; c < n; c++ {
+				sum -= a[row][c] * x[k][c]
+			}
+			x[k][row] = sum / a[row][row]
+		}
+	}
+	return x
+}
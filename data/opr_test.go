@@ -0,0 +1,104 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+// roundRobin plays every way of splitting six teams into two alliances once,
+// with every alliance scoring exactly what its teams contribute
+func roundRobin(contributions map[int]float64) []Match {
+	teams := []int{}
+	for team := range contributions {
+		teams = append(teams, team)
+	}
+	matches := []Match{}
+	for a := 1; a < 6; a++ {
+		for b := a + 1; b < 6; b++ {
+			// teams[0] is always red, so every split is only played once
+			red, blue := []int{teams[0], teams[a], teams[b]}, []int{}
+			for i := 1; i < 6; i++ {
+				if i != a && i != b {
+					blue = append(blue, teams[i])
+				}
+			}
+			match := Match{Number: len(matches) + 1, Red: red, Blue: blue, Scored: true}
+			for _, team := range red {
+				match.RedScore += int(contributions[team])
+			}
+			for _, team := range blue {
+				match.BlueScore += int(contributions[team])
+			}
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+func TestComputePowerRatings(t *testing.T) {
+	contributions := map[int]float64{254: 60, 971: 45, 4476: 30, 1678: 25, 118: 20, 100: 10}
+	matches := roundRobin(contributions)
+	// neither playoffs nor matches without a score count
+	matches = append(matches,
+		Match{Level: Playoff, Number: 1, Red: []int{254, 971, 99}, Blue: []int{1, 2, 3}, Scored: true, RedScore: 999},
+		Match{Number: 11, Red: []int{254, 971, 4476}, Blue: []int{1678, 118, 98}})
+	ratings := ComputePowerRatings(matches)
+
+	if len(ratings) != 6 {
+		t.Fatalf("rated %d teams, want 6", len(ratings))
+	}
+	// an alliance allows what the other three teams contribute, so each team
+	// is charged a third of everything less its own contribution
+	total := 190.0
+	for i, rating := range ratings {
+		opr, dpr := contributions[rating.Team], total/3-contributions[rating.Team]
+		if math.Abs(rating.OPR-opr) > 1e-3 || math.Abs(rating.DPR-dpr) > 1e-3 ||
+			math.Abs(rating.CCWM-(rating.OPR-rating.DPR)) > 1e-9 {
+			t.Errorf("team %d was rated %+v, want an OPR of %v and a DPR of %v", rating.Team, rating, opr, dpr)
+		}
+		if i > 0 && ratings[i-1].OPR < rating.OPR {
+			t.Errorf("team %d is rated after team %d", rating.Team, ratings[i-1].Team)
+		}
+	}
+}
+
+func TestComputePowerRatingsUnderdetermined(t *testing.T) {
+	// a single match can't tell partners apart, so they share the score
+	ratings := ComputePowerRatings([]Match{
+		{Number: 1, Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}, Scored: true, RedScore: 30, BlueScore: 60},
+	})
+	if len(ratings) != 6 {
+		t.Fatalf("rated %d teams, want 6", len(ratings))
+	}
+	for _, rating := range ratings {
+		opr, dpr := 10.0, 20.0
+		if rating.Team >= 4 {
+			opr, dpr = 20, 10
+		}
+		if math.Abs(rating.OPR-opr) > 1e-3 || math.Abs(rating.DPR-dpr) > 1e-3 || math.Abs(rating.CCWM-(opr-dpr)) > 1e-3 {
+			t.Errorf("team %d was rated %+v", rating.Team, rating)
+		}
+	}
+	if ratings[0].Team < 4 {
+		t.Errorf("team %d is rated first", ratings[0].Team)
+	}
+
+	if ratings := ComputePowerRatings(nil); ratings == nil || len(ratings) != 0 {
+		t.Errorf("ComputePowerRatings(nil) = %#v", ratings)
+	}
+}
+
+func TestGetPowerRatings(t *testing.T) {
+	db := newTestDB(t)
+	addTestCompetition(t, db)
+	for _, match := range roundRobin(map[int]float64{1: 6, 2: 5, 3: 4, 4: 3, 5: 2, 6: 1}) {
+		match.Competition = "2018casj"
+		if err := db.InsertMatch(match); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ratings, err := db.GetPowerRatings("2018casj")
+	if err != nil || len(ratings) != 6 || ratings[0].Team != 1 || math.Abs(ratings[0].OPR-6) > 1e-3 {
+		t.Errorf("GetPowerRatings = %+v, %v", ratings, err)
+	}
+}
@@ -0,0 +1,231 @@
+package data
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // registers the decoder used by image.Decode
+	"image/jpeg"
+	_ "image/png" // registers the decoder used by image.Decode
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// MaxPhotoSize is the largest photo, in bytes, that may be uploaded
+	MaxPhotoSize = 8 << 20
+	// MaxPhotoPixels is the most pixels an uploaded photo may have.  A few
+	// bytes can describe an enormous image, and decoding one takes four bytes
+	// of memory for every pixel.
+	MaxPhotoPixels = 32 << 20
+	// thumbnailSize is the length of the longest side of a thumbnail
+	thumbnailSize = 240
+	// photoDir holds a directory of uploaded photos for every year
+	photoDir = "photos"
+)
+
+// photoExtensions maps the content types of accepted photos to the extension
+// they're stored with
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Photo is a picture of a team's robot taken while pit scouting
+type Photo struct {
+	Id           int64
+	Team         int
+	Competition  string
+	UploaderId   int64
+	UploaderName string
+	Time         Timestamp
+	ContentType  string
+}
+
+// Path is where the full photo is stored
+func (photo Photo) Path(year int) string {
+	return filepath.Join(photoDir, strconv.Itoa(year), strconv.FormatInt(photo.Id, 10)+photoExtensions[photo.ContentType])
+}
+
+// ThumbnailPath is where the thumbnail of the photo is stored.  Thumbnails
+// are always JPEGs.
+func (photo Photo) ThumbnailPath(year int) string {
+	return filepath.Join(photoDir, strconv.Itoa(year), strconv.FormatInt(photo.Id, 10)+"-thumb.jpg")
+}
+
+// SavePhoto stores a photo of a team along with a thumbnail of it.  Only
+// JPEG, PNG and GIF images up to MaxPhotoSize bytes and MaxPhotoPixels pixels
+// are accepted.  Larger photos return ErrPhotoTooLarge, and anything else
+// returns ErrUnsupportedPhoto.
+func (db DB) SavePhoto(user *User, team int, competition string, r io.Reader) (*Photo, error) {
+	if user == nil {
+		return nil, ErrAccessDenied
+	}
+	if team <= 0 {
+		return nil, ErrInvalidTeam
+	}
+
+	contents, err := io.ReadAll(io.LimitReader(r, MaxPhotoSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) > MaxPhotoSize {
+		return nil, ErrPhotoTooLarge
+	}
+	contentType := http.DetectContentType(contents)
+	if _, ok := photoExtensions[contentType]; !ok {
+		return nil, ErrUnsupportedPhoto
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(contents))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrUnsupportedPhoto
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPhotoPixels {
+		return nil, ErrPhotoTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(contents))
+	if err != nil {
+		return nil, ErrUnsupportedPhoto
+	}
+
+	photo := &Photo{
+		Team:         team,
+		Competition:  competition,
+		UploaderId:   user.Id,
+		UploaderName: user.Username,
+		Time:         Now(),
+		ContentType:  contentType,
+	}
+	result, err := db.db.Exec(`INSERT INTO photos (team, competition, uploader, uploaded, content_type) VALUES (?, ?, ?, ?, ?)`,
+		photo.Team, photo.Competition, photo.UploaderId, photo.Time, photo.ContentType)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.SavePhoto: "+err.Error())
+		return nil, ErrDatabaseUpdate
+	}
+	if photo.Id, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Join(photoDir, strconv.Itoa(db.year)), 0755)
+	if err == nil {
+		err = os.WriteFile(photo.Path(db.year), contents, 0644)
+	}
+	if err == nil {
+		err = writeThumbnail(photo.ThumbnailPath(db.year), img)
+	}
+	if err != nil {
+		// don't leave behind a photo that can't be served
+		db.db.Exec(`DELETE FROM photos WHERE id=?`, photo.Id)
+		os.Remove(photo.Path(db.year))
+		return nil, err
+	}
+	return photo, nil
+}
+
+// GetPhoto retrieves a single photo by its id.  Returns ErrPhotoNotFound if
+// there is no such photo.
+func (db DB) GetPhoto(id int64) (*Photo, error) {
+	photos, err := db.queryPhotos(` WHERE p.id=?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(photos) == 0 {
+		return nil, ErrPhotoNotFound
+	}
+	return &photos[0], nil
+}
+
+// GetTeamPhotos retrieves every photo of a team, newest first
+func (db DB) GetTeamPhotos(team int) ([]Photo, error) {
+	return db.queryPhotos(` WHERE p.team=? ORDER BY p.uploaded DESC, p.id DESC`, team)
+}
+
+func (db DB) queryPhotos(where string, args ...interface{}) ([]Photo, error) {
+	rows, err := db.db.Query(`SELECT p.id, p.team, p.competition, p.uploader, u.username, p.uploaded, p.content_type
+ FROM photos p LEFT JOIN users u ON u.id=p.uploader`+where, args...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.queryPhotos: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []Photo{}
+	for rows.Next() {
+		var (
+			photo        Photo
+			uploaderName sql.NullString
+		)
+		err = rows.Scan(&photo.Id, &photo.Team, &photo.Competition, &photo.UploaderId, &uploaderName,
+			&photo.Time, &photo.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		photo.UploaderName = uploaderName.String
+		photos = append(photos, photo)
+	}
+	return photos, rows.Err()
+}
+
+// writeThumbnail shrinks the image to fit within thumbnailSize pixels and
+// saves it as a JPEG
+func writeThumbnail(name string, img image.Image) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = jpeg.Encode(f, thumbnail(img, thumbnailSize), &jpeg.Options{Quality: 80})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// thumbnail scales the image down so that its longest side is size pixels.
+// Each thumbnail pixel is the average of the block of pixels it covers.
+func thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	thumbWidth, thumbHeight := size, height*size/width
+	if height > width {
+		thumbWidth, thumbHeight = width*size/height, size
+	}
+	if thumbWidth < 1 {
+		thumbWidth = 1
+	}
+	if thumbHeight < 1 {
+		thumbHeight = 1
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for ty := 0; ty < thumbHeight; ty++ {
+		y0 := bounds.Min.Y + ty*height/thumbHeight
+		y1 := bounds.Min.Y + (ty+1)*height/thumbHeight
+		for tx := 0; tx < thumbWidth; tx++ {
+			x0 := bounds.Min.X + tx*width/thumbWidth
+			x1 := bounds.Min.X + (tx+1)*width/thumbWidth
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, pa := img.At(x, y).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			thumb.Set(tx, ty, color.RGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n),
+			})
+		}
+	}
+	return thumb
+}
@@ -0,0 +1,70 @@
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+)
+
+// gifHeader is the start of a GIF claiming to be width by height pixels,
+// which is as far as anything needs to read to find out how big it is
+func gifHeader(width, height uint16) []byte {
+	header := []byte("GIF89a")
+	header = binary.LittleEndian.AppendUint16(header, width)
+	header = binary.LittleEndian.AppendUint16(header, height)
+	return append(header, 0, 0, 0)
+}
+
+func TestSavePhoto(t *testing.T) {
+	// photos are stored relative to the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	db := newTestDB(t)
+	user := &User{Id: addTestUser(t, db, "scout", "a password", false), Username: "scout"}
+
+	var buffer bytes.Buffer
+	if err = png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, 640, 480))); err != nil {
+		t.Fatal(err)
+	}
+	photo, err := db.SavePhoto(user, 4476, "2018casj", &buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if photo.ContentType != "image/png" {
+		t.Errorf("the photo was saved as %q", photo.ContentType)
+	}
+	for _, path := range []string{photo.Path(testYear), photo.ThumbnailPath(testYear)} {
+		if _, err = os.Stat(path); err != nil {
+			t.Error(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		contents []byte
+		want     error
+	}{
+		{"too many pixels", gifHeader(65535, 65535), ErrPhotoTooLarge},
+		{"just too many pixels", gifHeader(8192, 4097), ErrPhotoTooLarge},
+		{"no pixels", gifHeader(0, 0), ErrUnsupportedPhoto},
+		{"only a signature", []byte("GIF89a"), ErrUnsupportedPhoto},
+		{"text", []byte("a robot"), ErrUnsupportedPhoto},
+	}
+	for _, test := range tests {
+		if _, err = db.SavePhoto(user, 4476, "2018casj", bytes.NewReader(test.contents)); err != test.want {
+			t.Errorf("saving a photo with %s = %v, want %v", test.name, err, test.want)
+		}
+	}
+	if photos, err := db.GetTeamPhotos(4476); err != nil || len(photos) != 1 {
+		t.Errorf("GetTeamPhotos = %+v, %v, want only the first photo", photos, err)
+	}
+}
@@ -0,0 +1,253 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// PickList is a named ordering of the teams at a competition, used to decide
+// who to pick during alliance selection.
+type PickList struct {
+	Id          int64
+	Competition string
+	Name        string
+	Entries     []PickListEntry
+}
+
+// PickListEntry is a single team's place on a pick list
+type PickListEntry struct {
+	Team      int
+	Note      string
+	DoNotPick bool
+
+	// Picked is true once the team has been picked by an alliance, at which
+	// point it no longer matters where it is on any list
+	Picked bool
+}
+
+// CanStrategize reports whether the user may see and change pick lists
+func (user *User) CanStrategize() bool {
+	return user != nil && (user.Strategist || user.Admin)
+}
+
+// GetPickLists retrieves every pick list of a competition without their
+// entries.
+func (db DB) GetPickLists(competition string) ([]PickList, error) {
+	rows, err := db.db.Query(`SELECT id, competition, name FROM pick_lists WHERE competition=? ORDER BY name`, competition)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.GetPickLists: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []PickList{}
+	for rows.Next() {
+		var list PickList
+		if err = rows.Scan(&list.Id, &list.Competition, &list.Name); err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, rows.Err()
+}
+
+// GetPickList retrieves a single pick list with its entries in order.
+// Returns ErrPickListNotFound if there is no such list.
+func (db DB) GetPickList(id int64) (*PickList, error) {
+	list := &PickList{}
+	err := db.db.QueryRow(`SELECT id, competition, name FROM pick_lists WHERE id=?`, id).
+		Scan(&list.Id, &list.Competition, &list.Name)
+	if err == sql.ErrNoRows {
+		return nil, ErrPickListNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	picked, err := db.GetPickedTeams(list.Competition)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.db.Query(`SELECT team, note, do_not_pick FROM pick_list_entries WHERE list=? ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry PickListEntry
+		if err = rows.Scan(&entry.Team, &entry.Note, &entry.DoNotPick); err != nil {
+			return nil, err
+		}
+		entry.Picked = picked[entry.Team]
+		list.Entries = append(list.Entries, entry)
+	}
+	return list, rows.Err()
+}
+
+// CreatePickList starts a new pick list containing every team attending the
+// competition in numerical order.  Returns ErrAccessDenied if the user isn't
+// a strategist.
+func (db DB) CreatePickList(user *User, competition, name string) (int64, error) {
+	if !user.CanStrategize() {
+		return 0, ErrAccessDenied
+	}
+	if name == "" {
+		return 0, ErrInvalidPickList
+	}
+	teams, err := db.GetCompetitionTeams(competition)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	result, err := tx.Exec(`INSERT INTO pick_lists (competition, name, creator) VALUES (?, ?, ?)`, competition, name, user.Id)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.CreatePickList: "+err.Error())
+		return 0, ErrDatabaseUpdate
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	for i, team := range teams {
+		_, err = tx.Exec(`INSERT INTO pick_list_entries (list, team, position, note, do_not_pick) VALUES (?, ?, ?, '', false)`,
+			id, team, i)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "data.CreatePickList: "+err.Error())
+			return 0, ErrDatabaseUpdate
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, ErrDatabaseUpdate
+	}
+	return id, nil
+}
+
+// ReorderPickList moves the teams of a pick list into the given order.  Every
+// team already on the list must be given exactly once.
+func (db DB) ReorderPickList(user *User, id int64, teams []int) error {
+	if !user.CanStrategize() {
+		return ErrAccessDenied
+	}
+	list, err := db.GetPickList(id)
+	if err != nil {
+		return err
+	}
+
+	onList := map[int]bool{}
+	for _, entry := range list.Entries {
+		onList[entry.Team] = true
+	}
+	if len(teams) != len(onList) {
+		return ErrInvalidPickList
+	}
+	for _, team := range teams {
+		if !onList[team] {
+			return ErrInvalidPickList
+		}
+		delete(onList, team) // catches duplicates
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	for position, team := range teams {
+		_, err = tx.Exec(`UPDATE pick_list_entries SET position=? WHERE list=? AND team=?`, position, id, team)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "data.ReorderPickList: "+err.Error())
+			return ErrDatabaseUpdate
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
+
+// AnnotatePickList sets the note of a team on a pick list and whether the
+// team should be picked at all.
+func (db DB) AnnotatePickList(user *User, id int64, team int, note string, doNotPick bool) error {
+	if !user.CanStrategize() {
+		return ErrAccessDenied
+	}
+	_, err := db.db.Exec(`UPDATE pick_list_entries SET note=?, do_not_pick=? WHERE list=? AND team=?`,
+		note, doNotPick, id, team)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.AnnotatePickList: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
+
+// DeletePickList removes a pick list and all of its entries
+func (db DB) DeletePickList(user *User, id int64) error {
+	if !user.CanStrategize() {
+		return ErrAccessDenied
+	}
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	if _, err = tx.Exec(`DELETE FROM pick_list_entries WHERE list=?`, id); err == nil {
+		_, err = tx.Exec(`DELETE FROM pick_lists WHERE id=?`, id)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.DeletePickList: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	if err = tx.Commit(); err != nil {
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
+
+// GetPickedTeams retrieves every team that has been picked during the
+// alliance selection of a competition.
+func (db DB) GetPickedTeams(competition string) (map[int]bool, error) {
+	rows, err := db.db.Query(`SELECT team FROM alliance_picks WHERE competition=?`, competition)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.GetPickedTeams: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	picked := map[int]bool{}
+	for rows.Next() {
+		var team int
+		if err = rows.Scan(&team); err != nil {
+			return nil, err
+		}
+		picked[team] = true
+	}
+	return picked, rows.Err()
+}
+
+// SetPicked records whether a team has been picked during the alliance
+// selection of a competition.  Picked teams drop out of every pick list.
+func (db DB) SetPicked(user *User, competition string, team int, picked bool) error {
+	if !user.CanStrategize() {
+		return ErrAccessDenied
+	}
+	_, err := db.db.Exec(`DELETE FROM alliance_picks WHERE competition=? AND team=?`, competition, team)
+	if err == nil && picked {
+		_, err = db.db.Exec(`INSERT INTO alliance_picks (competition, team, picked) VALUES (?, ?, ?)`,
+			competition, team, true)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.SetPicked: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
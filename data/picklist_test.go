@@ -0,0 +1,105 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+// pickListTeams lists the teams of a pick list in order
+func pickListTeams(list *PickList) []int {
+	teams := []int{}
+	for _, entry := range list.Entries {
+		teams = append(teams, entry.Team)
+	}
+	return teams
+}
+
+func TestPickLists(t *testing.T) {
+	db := newTestDB(t)
+	strategist := &User{Id: addTestUser(t, db, "strategist", "a password", false), Strategist: true}
+	scout := &User{Id: addTestUser(t, db, "scout", "a password", false)}
+	comp := Competition{Key: "2018casj", Name: "Silicon Valley Regional", Teams: []int{971, 254, 4476}}
+	if err := db.InsertCompetition(comp); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.CreatePickList(scout, comp.Key, "First pick"); err != ErrAccessDenied {
+		t.Errorf("a scout creating a pick list = %v, want ErrAccessDenied", err)
+	}
+	if _, err := db.CreatePickList(strategist, comp.Key, ""); err != ErrInvalidPickList {
+		t.Errorf("creating an unnamed pick list = %v, want ErrInvalidPickList", err)
+	}
+	id, err := db.CreatePickList(strategist, comp.Key, "First pick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := db.GetPickList(id)
+	if err != nil || list.Name != "First pick" || !reflect.DeepEqual(pickListTeams(list), []int{254, 971, 4476}) {
+		t.Fatalf("GetPickList = %+v, %v", list, err)
+	}
+	lists, err := db.GetPickLists(comp.Key)
+	if err != nil || len(lists) != 1 || lists[0].Id != id {
+		t.Errorf("GetPickLists = %+v, %v", lists, err)
+	}
+
+	for _, teams := range [][]int{{4476, 254}, {4476, 254, 254}, {4476, 254, 1}} {
+		if err = db.ReorderPickList(strategist, id, teams); err != ErrInvalidPickList {
+			t.Errorf("reordering the list as %v = %v, want ErrInvalidPickList", teams, err)
+		}
+	}
+	if err = db.ReorderPickList(scout, id, []int{4476, 971, 254}); err != ErrAccessDenied {
+		t.Errorf("a scout reordering the list = %v, want ErrAccessDenied", err)
+	}
+	if err = db.ReorderPickList(strategist, id, []int{4476, 971, 254}); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AnnotatePickList(strategist, id, 971, "fast intake", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = db.SetPicked(scout, comp.Key, 4476, true); err != ErrAccessDenied {
+		t.Errorf("a scout marking a team picked = %v, want ErrAccessDenied", err)
+	}
+	for _, team := range []int{4476, 254} {
+		if err = db.SetPicked(strategist, comp.Key, team, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// marking a team picked twice changes nothing
+	if err = db.SetPicked(strategist, comp.Key, 254, true); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.SetPicked(strategist, comp.Key, 254, false); err != nil {
+		t.Fatal(err)
+	}
+	picked, err := db.GetPickedTeams(comp.Key)
+	if err != nil || !reflect.DeepEqual(picked, map[int]bool{4476: true}) {
+		t.Errorf("GetPickedTeams = %v, %v", picked, err)
+	}
+
+	// the column is a boolean, which MySQL won't fill with anything else
+	var stored bool
+	if err = db.db.QueryRow(`SELECT picked FROM alliance_picks WHERE team=4476`).Scan(&stored); err != nil || !stored {
+		t.Errorf("the pick is stored as %v, %v", stored, err)
+	}
+
+	list, err = db.GetPickList(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PickListEntry{
+		{Team: 4476, Picked: true},
+		{Team: 971, Note: "fast intake", DoNotPick: true},
+		{Team: 254},
+	}
+	if !reflect.DeepEqual(list.Entries, want) {
+		t.Errorf("the entries are %+v, want %+v", list.Entries, want)
+	}
+
+	if err = db.DeletePickList(strategist, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.GetPickList(id); err != ErrPickListNotFound {
+		t.Errorf("GetPickList of a deleted list = %v, want ErrPickListNotFound", err)
+	}
+}
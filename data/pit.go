@@ -0,0 +1,278 @@
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PitRecord describes a single team's robot as it was at a competition.
+// There is at most one record per team per competition; older versions are
+// kept as revisions.
+type PitRecord struct {
+	Id          int64
+	Competition string
+	Team        int
+	ScoutId     int64
+	ScoutName   string
+	Time        Timestamp
+
+	// Values maps the name of every pit field to the value recorded for it
+	Values map[string]string
+}
+
+// Validate checks that the record is complete and that all of its values fit
+// the pit fields of the season.  Returns nil if the record is acceptable.
+func (record PitRecord) Validate(season *Season) error {
+	if record.Competition == "" {
+		return ErrInvalidCompetition
+	}
+	if record.Team <= 0 {
+		return ErrInvalidTeam
+	}
+	for _, field := range season.PitFields {
+		if !field.Validate(record.Values[field.Name]) {
+			return ErrInvalidField
+		}
+	}
+	if len(record.Values) != len(season.PitFields) {
+		return ErrInvalidField // there were values for fields that don't exist
+	}
+	return nil
+}
+
+// SavePitRecord stores the record of a team at a competition.  If the team
+// already has a record there, the old one becomes a revision.  On success,
+// the Id, scout and Time of the record are filled in.
+func (db DB) SavePitRecord(user *User, record *PitRecord) error {
+	if user == nil {
+		return ErrAccessDenied
+	}
+	season, err := GetSeason(db.year)
+	if err != nil {
+		return err
+	}
+	if err = record.Validate(season); err != nil {
+		return err
+	}
+	old, err := db.GetPitRecord(record.Competition, record.Team)
+	if err != nil && err != ErrPitRecordNotFound {
+		return err
+	}
+	record.ScoutId, record.ScoutName, record.Time = user.Id, user.Username, Now()
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	if old == nil {
+		var result sql.Result
+		result, err = tx.Exec(`INSERT INTO pit_records (competition, team, scout, updated) VALUES (?, ?, ?, ?)`,
+			record.Competition, record.Team, record.ScoutId, record.Time)
+		if err == nil {
+			record.Id, err = result.LastInsertId()
+		}
+	} else {
+		record.Id = old.Id
+		var previous []byte
+		previous, err = json.Marshal(old.Values)
+		if err == nil {
+			_, err = tx.Exec(`INSERT INTO pit_revisions (record, scout, updated, previous) VALUES (?, ?, ?, ?)`,
+				old.Id, old.ScoutId, old.Time, string(previous))
+		}
+		if err == nil {
+			_, err = tx.Exec(`UPDATE pit_records SET scout=?, updated=? WHERE id=?`, record.ScoutId, record.Time, record.Id)
+		}
+		if err == nil {
+			_, err = tx.Exec(`DELETE FROM pit_values WHERE record=?`, record.Id)
+		}
+	}
+	for name, value := range record.Values {
+		if err != nil {
+			break
+		}
+		_, err = tx.Exec(`INSERT INTO pit_values (record, field, value) VALUES (?, ?, ?)`, record.Id, name, value)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.SavePitRecord: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+
+	if err = tx.Commit(); err != nil {
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
+
+// GetPitRecord retrieves the current record of a team at a competition.
+// Returns ErrPitRecordNotFound if the team hasn't been pit scouted there.
+func (db DB) GetPitRecord(competition string, team int) (*PitRecord, error) {
+	records, err := db.queryPitRecords(` WHERE p.competition=? AND p.team=?`, competition, team)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrPitRecordNotFound
+	}
+	return &records[0], nil
+}
+
+// GetPitRecords retrieves the current record of every team at a competition,
+// or at every competition if it's empty, oldest first.
+func (db DB) GetPitRecords(competition string) ([]PitRecord, error) {
+	if competition == "" {
+		return db.queryPitRecords(` ORDER BY p.updated`)
+	}
+	return db.queryPitRecords(` WHERE p.competition=? ORDER BY p.updated`, competition)
+}
+
+// GetTeamPitRecords retrieves the current record of a team at every
+// competition it was pit scouted at, oldest first.
+func (db DB) GetTeamPitRecords(team int) ([]PitRecord, error) {
+	return db.queryPitRecords(` WHERE p.team=? ORDER BY p.updated`, team)
+}
+
+func (db DB) queryPitRecords(where string, args ...interface{}) ([]PitRecord, error) {
+	rows, err := db.db.Query(`SELECT p.id, p.competition, p.team, p.scout, u.username, p.updated
+ FROM pit_records p LEFT JOIN users u ON u.id=p.scout`+where, args...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.queryPitRecords: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []PitRecord{}
+	indices := map[int64]int{}
+	for rows.Next() {
+		var (
+			record    PitRecord
+			scoutName sql.NullString
+		)
+		err = rows.Scan(&record.Id, &record.Competition, &record.Team, &record.ScoutId, &scoutName, &record.Time)
+		if err != nil {
+			return nil, err
+		}
+		record.ScoutName = scoutName.String
+		record.Values = map[string]string{}
+		indices[record.Id] = len(records)
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil || len(records) == 0 {
+		return records, err
+	}
+
+	placeholders := make([]string, len(records))
+	ids := make([]interface{}, len(records))
+	for i, record := range records {
+		placeholders[i] = "?"
+		ids[i] = record.Id
+	}
+	valueRows, err := db.db.Query(
+		`SELECT record, field, value FROM pit_values WHERE record IN (`+strings.Join(placeholders, ",")+`)`, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer valueRows.Close()
+	for valueRows.Next() {
+		var (
+			id           int64
+			field, value string
+		)
+		if err = valueRows.Scan(&id, &field, &value); err != nil {
+			return nil, err
+		}
+		records[indices[id]].Values[field] = value
+	}
+	return records, valueRows.Err()
+}
+
+// GetPitRevisions retrieves the earlier versions of a pit record, oldest
+// first.
+func (db DB) GetPitRevisions(record PitRecord) ([]PitRecord, error) {
+	rows, err := db.db.Query(`SELECT r.scout, u.username, r.updated, r.previous
+ FROM pit_revisions r LEFT JOIN users u ON u.id=r.scout
+ WHERE r.record=? ORDER BY r.updated, r.id`, record.Id)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.GetPitRevisions: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []PitRecord{}
+	for rows.Next() {
+		var (
+			revision  = PitRecord{Id: record.Id, Competition: record.Competition, Team: record.Team}
+			scoutName sql.NullString
+			previous  string
+		)
+		if err = rows.Scan(&revision.ScoutId, &scoutName, &revision.Time, &previous); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(previous), &revision.Values); err != nil {
+			return nil, err
+		}
+		revision.ScoutName = scoutName.String
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// PitFilter selects teams by what their robot was recorded to be in the pits.
+// Enums and booleans must equal Value, text must contain it, and counters
+// are compared against it.  Counter values may be prefixed with <= or >=.
+type PitFilter struct {
+	Field string
+	Value string
+}
+
+// Matches reports whether the pit record passes the filter
+func (filter PitFilter) Matches(season *Season, record PitRecord) bool {
+	field := season.PitField(filter.Field)
+	if field == nil {
+		return false
+	}
+	value := record.Values[field.Name]
+
+	switch field.Kind {
+	case FieldText:
+		return strings.Contains(strings.ToLower(value), strings.ToLower(filter.Value))
+	case FieldCounter:
+		have, err := strconv.Atoi(value)
+		if err != nil {
+			return false
+		}
+		want := strings.TrimSpace(filter.Value)
+		compare := func(n int) bool { return have == n }
+		if strings.HasPrefix(want, "<=") {
+			want, compare = want[2:], func(n int) bool { return have <= n }
+		} else if strings.HasPrefix(want, ">=") {
+			want, compare = want[2:], func(n int) bool { return have >= n }
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(want))
+		return err == nil && compare(n)
+	}
+	return value == filter.Value
+}
+
+// FilterRankings keeps only the rankings of teams whose robots pass the
+// filter.  Teams are judged by their most recent record, so records should
+// be ordered oldest first.  Teams without a pit record are dropped.
+func FilterRankings(season *Season, rankings []Ranking, records []PitRecord, filter PitFilter) []Ranking {
+	latest := map[int]PitRecord{}
+	for _, record := range records {
+		latest[record.Team] = record
+	}
+
+	filtered := []Ranking{}
+	for _, ranking := range rankings {
+		if record, ok := latest[ranking.Team]; ok && filter.Matches(season, record) {
+			filtered = append(filtered, ranking)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,123 @@
+package data
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// testPitRecord is a record of a team at 2018casj with a robot of the weight
+func testPitRecord(team, weight int, drivetrain string) PitRecord {
+	return PitRecord{Competition: "2018casj", Team: team, Values: map[string]string{
+		"drivetrain": drivetrain, "width": "28", "length": "33", "height": "55", "weight": strconv.Itoa(weight),
+		"intake": "wheels", "lift": "1", "climber": "0", "language": "java", "mechanisms": "Forklift for partners",
+	}}
+}
+
+func TestSavePitRecord(t *testing.T) {
+	db := newTestDB(t)
+	addTestCompetition(t, db)
+	first := &User{Id: addTestUser(t, db, "first", "a password", false), Username: "first"}
+	second := &User{Id: addTestUser(t, db, "second", "a password", false), Username: "second"}
+
+	original := testPitRecord(4476, 110, "tank")
+	if err := db.SavePitRecord(nil, &original); err != ErrAccessDenied {
+		t.Errorf("saving without a user = %v, want ErrAccessDenied", err)
+	}
+	invalid := testPitRecord(4476, 130, "tank")
+	if err := db.SavePitRecord(first, &invalid); err != ErrInvalidField {
+		t.Errorf("saving a record that's too heavy = %v, want ErrInvalidField", err)
+	}
+	if _, err := db.GetPitRecord("2018casj", 4476); err != ErrPitRecordNotFound {
+		t.Errorf("GetPitRecord before saving = %v, want ErrPitRecordNotFound", err)
+	}
+
+	if err := db.SavePitRecord(first, &original); err != nil {
+		t.Fatal(err)
+	}
+	revised := testPitRecord(4476, 118, "swerve")
+	if err := db.SavePitRecord(second, &revised); err != nil {
+		t.Fatal(err)
+	}
+	latest := testPitRecord(4476, 119, "swerve")
+	if err := db.SavePitRecord(first, &latest); err != nil {
+		t.Fatal(err)
+	}
+	if revised.Id != original.Id || latest.Id != original.Id {
+		t.Errorf("revising the record changed its id from %d to %d and %d", original.Id, revised.Id, latest.Id)
+	}
+
+	stored, err := db.GetPitRecord("2018casj", 4476)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ScoutName != "first" || !reflect.DeepEqual(stored.Values, latest.Values) {
+		t.Errorf("GetPitRecord = %+v", stored)
+	}
+
+	// every earlier version is kept along with who recorded it
+	revisions, err := db.GetPitRevisions(*stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("GetPitRevisions found %d revisions, want 2", len(revisions))
+	}
+	for i, want := range []struct {
+		scout  *User
+		values map[string]string
+	}{{first, original.Values}, {second, revised.Values}} {
+		revision := revisions[i]
+		if revision.Id != stored.Id || revision.Team != 4476 || revision.ScoutId != want.scout.Id ||
+			revision.ScoutName != want.scout.Username || !reflect.DeepEqual(revision.Values, want.values) {
+			t.Errorf("revision %d is %+v", i, revision)
+		}
+	}
+
+	other := testPitRecord(254, 100, "tank")
+	if err = db.SavePitRecord(second, &other); err != nil {
+		t.Fatal(err)
+	}
+	if revisions, err = db.GetPitRevisions(other); err != nil || len(revisions) != 0 {
+		t.Errorf("a new record has the revisions %+v, %v", revisions, err)
+	}
+	if records, err := db.GetPitRecords("2018casj"); err != nil || len(records) != 2 {
+		t.Errorf("GetPitRecords = %+v, %v", records, err)
+	}
+	if records, err := db.GetTeamPitRecords(254); err != nil || len(records) != 1 || records[0].Values["weight"] != "100" {
+		t.Errorf("GetTeamPitRecords = %+v, %v", records, err)
+	}
+}
+
+func TestFilterRankings(t *testing.T) {
+	season := mustSeason()
+	records := []PitRecord{
+		testPitRecord(1, 110, "tank"), testPitRecord(2, 90, "swerve"),
+		testPitRecord(3, 120, "mecanum"), testPitRecord(1, 115, "swerve"), // team 1 rebuilt
+	}
+	records[2].Values["mechanisms"] = "Ramp"
+	rankings := []Ranking{{Team: 3}, {Team: 1}, {Team: 2}, {Team: 4}}
+
+	tests := []struct {
+		filter PitFilter
+		teams  []int
+	}{
+		{PitFilter{"drivetrain", "swerve"}, []int{1, 2}},
+		{PitFilter{"weight", ">=115"}, []int{3, 1}},
+		{PitFilter{"weight", "<= 90"}, []int{2}},
+		{PitFilter{"weight", "120"}, []int{3}},
+		{PitFilter{"weight", "heavy"}, []int{}},
+		{PitFilter{"mechanisms", "ramp"}, []int{3}},
+		{PitFilter{"lift", "1"}, []int{3, 1, 2}},
+		{PitFilter{"wheels", "4"}, []int{}},
+	}
+	for _, test := range tests {
+		teams := []int{}
+		for _, ranking := range FilterRankings(season, rankings, records, test.filter) {
+			teams = append(teams, ranking.Team)
+		}
+		if !reflect.DeepEqual(teams, test.teams) {
+			t.Errorf("filtering by %+v kept %v, want %v", test.filter, teams, test.teams)
+		}
+	}
+}
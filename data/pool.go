@@ -0,0 +1,81 @@
+package data
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// PoolConfig limits the connections kept to the database of each year
+type PoolConfig struct {
+	// MaxOpen is the most connections open at once, or 0 for no limit
+	MaxOpen int
+	// MaxIdle is the most connections kept open while unused
+	MaxIdle int
+	// MaxLifetime is how long a connection is reused before it's replaced, or
+	// 0 to reuse them forever
+	MaxLifetime time.Duration
+}
+
+// DefaultPoolConfig suits an event where every scout submits at once after a
+// match without the database server having to take a connection per tablet
+var DefaultPoolConfig = PoolConfig{MaxOpen: 32, MaxIdle: 16, MaxLifetime: 30 * time.Minute}
+
+var (
+	poolMutex  sync.Mutex
+	poolConfig = DefaultPoolConfig
+	pools      = map[int]*sql.DB{}
+)
+
+// SetPoolConfig changes the limits of the databases shared from then on.
+// Databases already shared are changed too.
+func SetPoolConfig(config PoolConfig) {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	poolConfig = config
+	for _, pool := range pools {
+		configurePool(pool, config)
+	}
+}
+
+func configurePool(pool *sql.DB, config PoolConfig) {
+	pool.SetMaxOpenConns(config.MaxOpen)
+	pool.SetMaxIdleConns(config.MaxIdle)
+	pool.SetConnMaxLifetime(config.MaxLifetime)
+}
+
+// GetDatabase returns the database of a year shared by the whole program,
+// connecting to it the first time.  Unlike ConnectToDatabase, the connection
+// lasts until CloseDatabases is called, so never close it yourself.
+func GetDatabase(year int) (DB, error) {
+	if !VerifyYear(year) {
+		return DB{}, ErrWrongYear
+	}
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	pool, ok := pools[year]
+	if !ok {
+		var err error
+		if pool, err = backend.Open(year); err != nil {
+			return DB{}, err
+		}
+		configurePool(pool, poolConfig)
+		pools[year] = pool
+	}
+	return DB{db: pool, year: year}, nil
+}
+
+// CloseDatabases closes every database shared by GetDatabase, which may be
+// connected to again afterwards.  Returns the first error from closing them.
+func CloseDatabases() error {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	var firstErr error
+	for year, pool := range pools {
+		if err := pool.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(pools, year)
+	}
+	return firstErr
+}
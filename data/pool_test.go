@@ -0,0 +1,115 @@
+package data
+
+import (
+	"os"
+	"testing"
+)
+
+// useMemory connects every database to a backend held in memory until the
+// test is over
+func useMemory(tb testing.TB) {
+	old := backend
+	SetBackend(NewMemory())
+	tb.Cleanup(func() {
+		CloseDatabases()
+		SetBackend(old)
+	})
+}
+
+func TestGetDatabaseShared(t *testing.T) {
+	useMemory(t)
+	first, err := GetDatabase(testYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := GetDatabase(testYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.db != second.db {
+		t.Error("GetDatabase connected to the same year twice")
+	}
+	if _, err = GetDatabase(MinYear - 1); err != ErrWrongYear {
+		t.Errorf("GetDatabase(%d) = %v, want ErrWrongYear", MinYear-1, err)
+	}
+
+	if err = CloseDatabases(); err != nil {
+		t.Fatal(err)
+	}
+	third, err := GetDatabase(testYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.db == first.db {
+		t.Error("GetDatabase shared a closed database")
+	}
+}
+
+// benchmarkRequest does what a typical request does with its database
+func benchmarkRequest(db DB) error {
+	_, err := db.GetCompetitions()
+	return err
+}
+
+// BenchmarkGetDatabase compares sharing a pool of connections between
+// requests with connecting to the database for every request, as handlers
+// used to, while serving many requests at once
+func BenchmarkGetDatabase(b *testing.B) {
+	useMemory(b)
+	benchmarkGetDatabase(b)
+}
+
+// BenchmarkGetDatabaseMySQL compares the same against a MySQL server, where
+// connecting costs a round trip.  It only runs if SCOUT_TEST_MYSQL holds the
+// data source name of the server up to the year, such as
+// scout@/scouting_test; the database of testYear is created if needed.
+func BenchmarkGetDatabaseMySQL(b *testing.B) {
+	prefix := os.Getenv("SCOUT_TEST_MYSQL")
+	if prefix == "" {
+		b.Skip("SCOUT_TEST_MYSQL isn't set")
+	}
+	old := backend
+	SetBackend(MySQL{Prefix: prefix})
+	b.Cleanup(func() {
+		CloseDatabases()
+		SetBackend(old)
+	})
+	if _, err := Migrate(testYear, false); err != nil {
+		b.Fatal(err)
+	}
+	benchmarkGetDatabase(b)
+}
+
+func benchmarkGetDatabase(b *testing.B) {
+	b.Run("shared", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				db, err := GetDatabase(testYear)
+				if err == nil {
+					err = benchmarkRequest(db)
+				}
+				if err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+	b.Run("per-request", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				db, err := ConnectToDatabase(testYear)
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				err = benchmarkRequest(db)
+				db.Close()
+				if err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,138 @@
+package data
+
+import (
+	"math"
+	"time"
+)
+
+// Prediction estimates the outcome of a single match from what the teams in
+// it were scouted doing in earlier matches.
+type Prediction struct {
+	RedScore  float64
+	BlueScore float64
+
+	// RedWinProbability is the chance that the red alliance outscores the
+	// blue alliance.  The blue alliance's chance is one minus this.
+	RedWinProbability float64
+
+	// Unscouted is the number of teams in the match without any submissions.
+	// They are assumed to score nothing, so the prediction is less reliable
+	// the higher this is.
+	Unscouted int
+}
+
+// Winner is the alliance that's expected to win
+func (prediction Prediction) Winner() Alliance {
+	if prediction.RedWinProbability < 0.5 {
+		return Blue
+	}
+	return Red
+}
+
+// MatchPrediction pairs a match with its prediction
+type MatchPrediction struct {
+	Match      Match
+	Prediction Prediction
+}
+
+// Predict estimates the score of each alliance in the match along with the
+// chance that red wins.  Each team's contribution is the average of the
+// points it was scouted scoring.  The difference between the alliances is
+// treated as normally distributed, with each team adding its own variance.
+func (analysis *Analysis) Predict(match Match) Prediction {
+	var prediction Prediction
+	variance := 0.0
+	addAlliance := func(teams []int, score *float64) {
+		for _, team := range teams {
+			summary, ok := analysis.Team(team)
+			if !ok || summary.Points.Count == 0 {
+				prediction.Unscouted++
+				continue
+			}
+			*score += summary.Points.Mean
+			variance += summary.Points.StdDev * summary.Points.StdDev
+		}
+	}
+	addAlliance(match.Red, &prediction.RedScore)
+	addAlliance(match.Blue, &prediction.BlueScore)
+
+	margin := prediction.RedScore - prediction.BlueScore
+	switch {
+	case variance > 0:
+		prediction.RedWinProbability = 0.5 * (1 + math.Erf(margin/math.Sqrt(2*variance)))
+	case margin > 0:
+		prediction.RedWinProbability = 1
+	case margin < 0:
+		prediction.RedWinProbability = 0
+	default:
+		prediction.RedWinProbability = 0.5
+	}
+	return prediction
+}
+
+// PredictMatches predicts every match of a competition, or of every
+// competition if it's empty.  Each match is predicted only from what was
+// scouted before it, so matches that have been played are predicted as they
+// would have been beforehand and can fairly be compared to their results.
+func (db DB) PredictMatches(competition string) ([]MatchPrediction, error) {
+	matches, err := db.GetMatches(competition)
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := db.Analyze(competition)
+	if err != nil {
+		return nil, err
+	}
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return nil, err
+	}
+	starts := map[string]time.Time{}
+	for _, comp := range competitions {
+		starts[comp.Key] = comp.Start.time
+	}
+
+	predictions := make([]MatchPrediction, len(matches))
+	for i, match := range matches {
+		predictions[i] = MatchPrediction{Match: match, Prediction: analysis.before(match, starts).Predict(match)}
+	}
+	return predictions, nil
+}
+
+// before narrows the analysis down to the teams of a match as they were
+// scouted before it was played.  starts maps the key of every competition to
+// when it started.
+func (analysis *Analysis) before(match Match, starts map[string]time.Time) *Analysis {
+	earlier := &Analysis{Season: analysis.Season, submissions: map[int][]Submission{}}
+	for _, team := range match.Teams() {
+		if _, ok := earlier.submissions[team]; ok {
+			continue
+		}
+		subs := []Submission{}
+		for _, sub := range analysis.submissions[team] {
+			if scoutedBefore(sub, match, starts) {
+				subs = append(subs, sub)
+			}
+		}
+		earlier.submissions[team] = subs
+		if len(subs) > 0 {
+			earlier.Teams = append(earlier.Teams, SummarizeTeam(analysis.Season, team, subs))
+		}
+	}
+	return earlier
+}
+
+// scoutedBefore reports whether a submission is about a match played before
+// the given one: an earlier match of the same competition, or any match of a
+// competition that started earlier
+func scoutedBefore(sub Submission, match Match, starts map[string]time.Time) bool {
+	if sub.Competition != match.Competition {
+		subStart, ok := starts[sub.Competition]
+		matchStart, matchOk := starts[match.Competition]
+		return ok && matchOk && subStart.Before(matchStart)
+	}
+	if sub.Level != match.Level {
+		return sub.Level == Qualification
+	}
+	return sub.Match < match.Number
+}
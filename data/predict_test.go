@@ -0,0 +1,113 @@
+package data
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+// pointsSubmission is a submission about a team in a qualification match
+// that's worth 5 points for every cube placed on the scale and nothing else
+func pointsSubmission(competition string, match, team, scale int) Submission {
+	values := map[string]string{}
+	for _, field := range mustSeason().Fields {
+		values[field.Name] = "0"
+	}
+	values["endgame"], values["comments"], values["scale"] = "none", "", strconv.Itoa(scale)
+	return Submission{Competition: competition, Match: match, Team: team, Values: values}
+}
+
+func mustSeason() *Season {
+	season, err := GetSeason(testYear)
+	if err != nil {
+		panic(err)
+	}
+	return season
+}
+
+func TestPredict(t *testing.T) {
+	season := mustSeason()
+	subs := []Submission{
+		pointsSubmission("2018casj", 1, 1, 10), pointsSubmission("2018casj", 2, 1, 12),
+		pointsSubmission("2018casj", 1, 2, 4), pointsSubmission("2018casj", 2, 2, 4),
+		pointsSubmission("2018casj", 1, 4, 2), pointsSubmission("2018casj", 2, 4, 2),
+	}
+	analysis := &Analysis{Season: season, Teams: SummarizeTeams(season, subs)}
+
+	prediction := analysis.Predict(Match{Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}})
+	if prediction.RedScore != 55+20 || prediction.BlueScore != 10 || prediction.Unscouted != 3 {
+		t.Errorf("Predict = %+v", prediction)
+	}
+	// only team 1 varies, by 5 points either way, so red is certain to win
+	if prediction.RedWinProbability < 0.999 || prediction.Winner() != Red {
+		t.Errorf("red wins with probability %v", prediction.RedWinProbability)
+	}
+
+	swapped := analysis.Predict(Match{Red: []int{4, 5, 6}, Blue: []int{1, 2, 3}})
+	if math.Abs(swapped.RedWinProbability+prediction.RedWinProbability-1) > 1e-9 || swapped.Winner() != Blue {
+		t.Errorf("swapping the alliances gave %+v", swapped)
+	}
+
+	// alliances that can't be told apart are a coin toss
+	even := analysis.Predict(Match{Red: []int{2, 7, 8}, Blue: []int{2, 9, 10}})
+	if even.RedWinProbability != 0.5 || even.RedScore != even.BlueScore {
+		t.Errorf("even alliances gave %+v", even)
+	}
+	// a certain margin without any variance
+	sure := analysis.Predict(Match{Red: []int{4, 0, 0}, Blue: []int{2, 0, 0}})
+	if sure.RedWinProbability != 0 || sure.Unscouted != 4 {
+		t.Errorf("a certain loss gave %+v", sure)
+	}
+}
+
+func TestPredictMatches(t *testing.T) {
+	db := newTestDB(t)
+	t.Cleanup(func() { invalidateAnalyses(db.year) })
+	if err := db.InsertCompetition(Competition{Key: "2018casj", Name: "Silicon Valley Regional"}); err != nil {
+		t.Fatal(err)
+	}
+	for number := 1; number <= 3; number++ {
+		match := Match{Competition: "2018casj", Number: number, Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}}
+		if err := db.InsertMatch(match); err != nil {
+			t.Fatal(err)
+		}
+	}
+	playoff := Match{Competition: "2018casj", Level: Playoff, Number: 1, Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}}
+	if err := db.InsertMatch(playoff); err != nil {
+		t.Fatal(err)
+	}
+	// team 1 scores more every match, so any hindsight would show
+	for number, scale := range map[int]int{1: 2, 2: 4, 3: 30} {
+		sub := pointsSubmission("2018casj", number, 1, scale)
+		if err := db.InsertSubmission(&sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.SetMatchScore("2018casj", Qualification, 3, 200, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	predictions, err := db.PredictMatches("2018casj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"qual 1": 0, "qual 2": 10, "qual 3": 15, "playoff 1": 60}
+	if len(predictions) != len(want) {
+		t.Fatalf("PredictMatches predicted %d matches, want %d", len(predictions), len(want))
+	}
+	for _, prediction := range predictions {
+		name := prediction.Match.Level.String() + " " + strconv.Itoa(prediction.Match.Number)
+		if prediction.Prediction.RedScore != want[name] {
+			t.Errorf("red is predicted to score %v in %s, want %v", prediction.Prediction.RedScore, name, want[name])
+		}
+	}
+	if first := predictions[0].Prediction; first.Unscouted != 6 {
+		t.Errorf("the first match was predicted with %d teams unscouted, want 6", first.Unscouted)
+	}
+
+	// every match of the year is predicted the same way
+	all, err := db.PredictMatches("")
+	if err != nil || len(all) != len(predictions) || all[2].Prediction != predictions[2].Prediction {
+		t.Errorf("PredictMatches of every competition = %+v, %v", all, err)
+	}
+}
@@ -0,0 +1,233 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// flagDeviations is how many standard deviations an alliance's scouted
+	// total may stray from the official score before its submissions are
+	// flagged
+	flagDeviations = 2.5
+	// minComparedAlliances is how many alliances must be compared before
+	// any are flagged for straying, since the spread of a few isn't telling
+	minComparedAlliances = 5
+	// disagreeMinimum and disagreeShare decide when two scouts watching the
+	// same robot disagree: their estimates must differ by more than
+	// disagreeMinimum points and by more than disagreeShare of the larger one
+	disagreeMinimum = 10
+	disagreeShare   = 0.25
+)
+
+// ScoutReliability measures how well one scout's submissions agree with the
+// official scores of the matches they watched.  Every compared submission is
+// charged an equal share of how far its alliance's scouted total missed the
+// official score, plus how far it strays from other scouts watching the same
+// robot.  Since official scores include points no scout records, such as
+// fouls, the typical miss of every alliance is taken out first.
+type ScoutReliability struct {
+	ScoutId     int64
+	ScoutName   string
+	Submissions int
+	// Compared is how many submissions were part of an alliance whose every
+	// robot was scouted in a scored match
+	Compared int
+	// Bias is the average error charged to the scout's submissions.  A scout
+	// who records more than happened has a positive bias.
+	Bias float64
+	// Error is the average size of the error charged to the scout's
+	// submissions; the lower, the more trustworthy.
+	Error float64
+	// Flagged is how many of the scout's submissions were flagged
+	Flagged int
+}
+
+// FlaggedSubmission is a submission that deserves a second look, along with
+// why.  A submission is flagged at most once, with every reason joined by
+// semicolons.
+type FlaggedSubmission struct {
+	Submission
+	Reason string
+}
+
+// Reliability is how trustworthy the scouting of a set of matches was
+type Reliability struct {
+	// Scouts are ordered by Error, least reliable first
+	Scouts []ScoutReliability
+	// Flagged submissions are ordered by id, so the oldest come first
+	Flagged []FlaggedSubmission
+	// Bias is how much the scouted total of an alliance typically misses its
+	// official score by
+	Bias float64
+	// Alliances is how many alliances could be compared
+	Alliances int
+}
+
+// allianceComparison is an alliance whose every robot was scouted
+type allianceComparison struct {
+	robots int
+	subs   []Submission
+	error  []float64 // of each submission before the typical miss is taken out
+	miss   float64
+}
+
+// MeasureReliability compares the submissions against the official scores of
+// the matches they were made for and against each other.
+func MeasureReliability(season *Season, matches []Match, subs []Submission) *Reliability {
+	byRobot := map[string][]Submission{}
+	scouts := map[int64]*ScoutReliability{}
+	for _, sub := range subs {
+		key := fmt.Sprintf("%s/%d", matchKey(sub.Competition, sub.Level, sub.Match), sub.Team)
+		byRobot[key] = append(byRobot[key], sub)
+		if scouts[sub.ScoutId] == nil {
+			scouts[sub.ScoutId] = &ScoutReliability{ScoutId: sub.ScoutId, ScoutName: sub.ScoutName}
+		}
+		scouts[sub.ScoutId].Submissions++
+	}
+
+	reliability := &Reliability{Scouts: []ScoutReliability{}, Flagged: []FlaggedSubmission{}}
+	flagged := map[int64]int{} // the index in Flagged of each flagged submission
+	flag := func(sub Submission, reason string) {
+		if i, ok := flagged[sub.Id]; ok {
+			reliability.Flagged[i].Reason += "; " + reason
+			return
+		}
+		flagged[sub.Id] = len(reliability.Flagged)
+		reliability.Flagged = append(reliability.Flagged, FlaggedSubmission{sub, reason})
+		scouts[sub.ScoutId].Flagged++
+	}
+
+	comparisons := []allianceComparison{}
+	for _, match := range matches {
+		prefix := matchKey(match.Competition, match.Level, match.Number)
+		for _, robot := range match.Teams() {
+			disagreements(season, byRobot[fmt.Sprintf("%s/%d", prefix, robot)], flag)
+		}
+		if !match.Scored {
+			continue
+		}
+		for _, alliance := range []Alliance{Red, Blue} {
+			teams, official := match.Red, match.RedScore
+			if alliance == Blue {
+				teams, official = match.Blue, match.BlueScore
+			}
+			comparison, ok := compareAlliance(season, teams, official, prefix, byRobot)
+			if ok {
+				comparisons = append(comparisons, comparison)
+			}
+		}
+	}
+
+	reliability.Alliances = len(comparisons)
+	for _, comparison := range comparisons {
+		reliability.Bias += comparison.miss / float64(len(comparisons))
+	}
+	spread := 0.0
+	for _, comparison := range comparisons {
+		spread += (comparison.miss - reliability.Bias) * (comparison.miss - reliability.Bias)
+	}
+	if len(comparisons) > 0 {
+		spread = math.Sqrt(spread / float64(len(comparisons)))
+	}
+
+	for _, comparison := range comparisons {
+		share := reliability.Bias / float64(comparison.robots)
+		strays := len(comparisons) >= minComparedAlliances && spread > 0 &&
+			math.Abs(comparison.miss-reliability.Bias) > flagDeviations*spread
+		for i, sub := range comparison.subs {
+			scout := scouts[sub.ScoutId]
+			err := comparison.error[i] - share
+			scout.Compared++
+			scout.Bias += err
+			scout.Error += math.Abs(err)
+			if strays {
+				flag(sub, fmt.Sprintf("the alliance was scouted %+.0f points from its official score",
+					comparison.miss-reliability.Bias))
+			}
+		}
+	}
+
+	for _, scout := range scouts {
+		if scout.Compared > 0 {
+			scout.Bias /= float64(scout.Compared)
+			scout.Error /= float64(scout.Compared)
+		}
+		reliability.Scouts = append(reliability.Scouts, *scout)
+	}
+	sort.SliceStable(reliability.Flagged, func(i, j int) bool {
+		return reliability.Flagged[i].Id < reliability.Flagged[j].Id
+	})
+	sort.Slice(reliability.Scouts, func(i, j int) bool {
+		a, b := reliability.Scouts[i], reliability.Scouts[j]
+		if a.Error != b.Error {
+			return a.Error > b.Error
+		}
+		return a.ScoutName < b.ScoutName
+	})
+	return reliability
+}
+
+// compareAlliance finds how far the scouted total of an alliance missed its
+// official score, and how much of the miss each submission is charged with.
+// Returns false unless every robot of the alliance was scouted.
+func compareAlliance(season *Season, teams []int, official int, prefix string,
+	byRobot map[string][]Submission) (allianceComparison, bool) {
+	comparison := allianceComparison{robots: len(teams), miss: -float64(official)}
+	robots := map[int]float64{} // the average points scouted for each robot
+	for _, team := range teams {
+		robotSubs := byRobot[fmt.Sprintf("%s/%d", prefix, team)]
+		if len(robotSubs) == 0 {
+			return comparison, false
+		}
+		for _, sub := range robotSubs {
+			robots[team] += season.Points(sub) / float64(len(robotSubs))
+		}
+		comparison.miss += robots[team]
+		comparison.subs = append(comparison.subs, robotSubs...)
+	}
+	for _, sub := range comparison.subs {
+		// each robot is charged an equal share of the miss, and each scout of
+		// a robot is charged for straying from the robot's average
+		comparison.error = append(comparison.error,
+			comparison.miss/float64(len(teams))+season.Points(sub)-robots[sub.Team])
+	}
+	return comparison, true
+}
+
+// disagreements flags every pair of submissions about the same robot in the
+// same match whose estimated points are far apart
+func disagreements(season *Season, subs []Submission, flag func(Submission, string)) {
+	for i, a := range subs {
+		for j, b := range subs {
+			if i == j {
+				continue
+			}
+			pointsA, pointsB := season.Points(a), season.Points(b)
+			if math.Abs(pointsA-pointsB) > math.Max(disagreeMinimum, disagreeShare*math.Max(pointsA, pointsB)) {
+				flag(a, fmt.Sprintf("disagrees with %s's submission %d (%.0f points against %.0f)",
+					b.ScoutName, b.Id, pointsA, pointsB))
+				break
+			}
+		}
+	}
+}
+
+// GetReliability measures the reliability of every scout at a competition,
+// or during the whole year if competition is empty
+func (db DB) GetReliability(competition string) (*Reliability, error) {
+	season, err := GetSeason(db.year)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := db.GetMatches(competition)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := db.GetSubmissions(SubmissionFilter{Competition: competition})
+	if err != nil {
+		return nil, err
+	}
+	return MeasureReliability(season, matches, subs), nil
+}
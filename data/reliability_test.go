@@ -0,0 +1,131 @@
+package data
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// reliabilityMatch is a scored match between teams 1, 2 and 3 on red and 4,
+// 5 and 6 on blue
+func reliabilityMatch(number, red, blue int) Match {
+	return Match{Competition: "2018casj", Number: number, Red: []int{1, 2, 3}, Blue: []int{4, 5, 6},
+		Scored: true, RedScore: red, BlueScore: blue}
+}
+
+// scoutedBy makes a submission worth scale*5 points, made by a scout
+func scoutedBy(id, scout int64, match, team, scale int) Submission {
+	sub := pointsSubmission("2018casj", match, team, scale)
+	sub.Id, sub.ScoutId, sub.ScoutName = id, scout, "Scout "+string(rune('A'+scout-1))
+	return sub
+}
+
+// scoutMatch makes a submission worth 10 points for every robot of a match,
+// all by the same scout
+func scoutMatch(firstId, scout int64, match int) []Submission {
+	subs := []Submission{}
+	for team := 1; team <= 6; team++ {
+		subs = append(subs, scoutedBy(firstId+int64(team), scout, match, team, 2))
+	}
+	return subs
+}
+
+func TestMeasureReliability(t *testing.T) {
+	// red is scouted 6 points short and blue exactly, so the typical miss is
+	// -3 and red's scout is charged 1 point too few for each robot
+	subs := []Submission{}
+	for team := 1; team <= 3; team++ {
+		subs = append(subs, scoutedBy(int64(team), 1, 1, team, 2))
+	}
+	for team := 4; team <= 6; team++ {
+		subs = append(subs, scoutedBy(int64(team), 2, 1, team, 4))
+	}
+	reliability := MeasureReliability(mustSeason(), []Match{reliabilityMatch(1, 36, 60)}, subs)
+
+	if reliability.Alliances != 2 || reliability.Bias != -3 || len(reliability.Flagged) != 0 {
+		t.Errorf("MeasureReliability = %+v", reliability)
+	}
+	if len(reliability.Scouts) != 2 {
+		t.Fatalf("%d scouts were measured", len(reliability.Scouts))
+	}
+	for _, scout := range reliability.Scouts {
+		want := map[int64]float64{1: -1, 2: 1}[scout.ScoutId]
+		if scout.Compared != 3 || math.Abs(scout.Bias-want) > 1e-9 || math.Abs(scout.Error-1) > 1e-9 {
+			t.Errorf("scout %d = %+v, want a bias of %v and an error of 1", scout.ScoutId, scout, want)
+		}
+	}
+
+	// an unscored match can't be compared
+	unscored := reliabilityMatch(1, 0, 0)
+	unscored.Scored = false
+	if reliability := MeasureReliability(mustSeason(), []Match{unscored}, subs); reliability.Alliances != 0 ||
+		reliability.Scouts[0].Compared != 0 || reliability.Scouts[0].Error != 0 {
+		t.Errorf("an unscored match gave %+v", reliability)
+	}
+}
+
+func TestMeasureReliabilityDisagreements(t *testing.T) {
+	subs := append(scoutMatch(0, 1, 1),
+		scoutedBy(10, 2, 1, 1, 3), // 15 points against 10 is close enough
+		scoutedBy(11, 3, 1, 2, 8)) // 40 points against 10 isn't
+	unscored := reliabilityMatch(1, 0, 0)
+	unscored.Scored = false
+	reliability := MeasureReliability(mustSeason(), []Match{unscored}, subs)
+
+	if len(reliability.Flagged) != 2 || reliability.Flagged[0].Id != 2 || reliability.Flagged[1].Id != 11 {
+		t.Fatalf("flagged %+v, want submissions 2 and 11", reliability.Flagged)
+	}
+	if reason := reliability.Flagged[1].Reason; reason != "disagrees with Scout A's submission 2 (40 points against 10)" {
+		t.Errorf("submission 11 was flagged because it %s", reason)
+	}
+	for _, scout := range reliability.Scouts {
+		if want := map[int64]int{1: 1, 2: 0, 3: 1}[scout.ScoutId]; scout.Flagged != want {
+			t.Errorf("scout %d had %d submissions flagged, want %d", scout.ScoutId, scout.Flagged, want)
+		}
+	}
+}
+
+func TestMeasureReliabilityStrays(t *testing.T) {
+	// every alliance is scouted exactly except red in the last match, whose
+	// team 1 is scouted at 10 and 100 points by two scouts who disagree
+	matches, subs := []Match{}, []Submission{}
+	for match := 1; match <= 4; match++ {
+		matches = append(matches, reliabilityMatch(match, 30, 30))
+		if match < 4 {
+			subs = append(subs, scoutMatch(int64(match*10), 3, match)...)
+		}
+	}
+	subs = append(subs, scoutedBy(41, 1, 4, 1, 2), scoutedBy(42, 2, 4, 1, 20))
+	for team := 2; team <= 6; team++ {
+		subs = append(subs, scoutedBy(int64(40+team+1), 3, 4, team, 2))
+	}
+	reliability := MeasureReliability(mustSeason(), matches, subs)
+
+	if reliability.Alliances != 8 {
+		t.Errorf("%d alliances were compared, want 8", reliability.Alliances)
+	}
+	flagged := map[int64]string{}
+	for _, sub := range reliability.Flagged {
+		if _, ok := flagged[sub.Id]; ok {
+			t.Errorf("submission %d was flagged twice", sub.Id)
+		}
+		flagged[sub.Id] = sub.Reason
+	}
+	if len(flagged) != 4 {
+		t.Errorf("flagged %v, want the 4 submissions about red in match 4", flagged)
+	}
+	for _, id := range []int64{41, 42} {
+		if reasons := strings.Split(flagged[id], "; "); len(reasons) != 2 ||
+			!strings.HasPrefix(reasons[0], "disagrees with") || !strings.HasSuffix(reasons[1], "official score") {
+			t.Errorf("submission %d was flagged because it %s", id, flagged[id])
+		}
+	}
+	if !strings.HasPrefix(flagged[43], "the alliance was scouted +39 points") {
+		t.Errorf("submission 43 was flagged because %s", flagged[43])
+	}
+	for _, scout := range reliability.Scouts {
+		if want := map[int64]int{1: 1, 2: 1, 3: 2}[scout.ScoutId]; scout.Flagged != want {
+			t.Errorf("scout %d had %d submissions flagged, want %d", scout.ScoutId, scout.Flagged, want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package data
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FieldKind describes what sort of value a game field holds
+type FieldKind int
+
+const (
+	// FieldCounter is a field that counts how many times something happened
+	FieldCounter FieldKind = iota
+	// FieldBoolean is a field that records whether or not something happened
+	FieldBoolean
+	// FieldEnum is a field that holds one of a fixed list of options
+	FieldEnum
+	// FieldText is a field that holds free-form text
+	FieldText
+)
+
+var fieldKindNames = []string{"counter", "boolean", "enum", "text"}
+
+// String returns the name used for the kind in season files
+func (kind FieldKind) String() string {
+	if kind < 0 || int(kind) >= len(fieldKindNames) {
+		return "unknown"
+	}
+	return fieldKindNames[kind]
+}
+
+// UnmarshalJSON reads a kind from its name in a season file
+func (kind *FieldKind) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+	for i, n := range fieldKindNames {
+		if n == name {
+			*kind = FieldKind(i)
+			return nil
+		}
+	}
+	return ErrInvalidSeason
+}
+
+// Field describes a single game-specific piece of information recorded in a
+// submission.  For counters, Min and Max bound the value.  For text, they
+// bound its length.
+//
+// Points is what the alliance scores for every count of a counter or for a
+// true boolean.  OptionPoints is what the alliance scores for each option of
+// an enum.
+type Field struct {
+	Name         string             `json:"name"`
+	Label        string             `json:"label"`
+	Kind         FieldKind          `json:"kind"`
+	Min          int                `json:"min"`
+	Max          int                `json:"max"`
+	Options      []string           `json:"options"`
+	Points       float64            `json:"points"`
+	OptionPoints map[string]float64 `json:"optionPoints"`
+}
+
+// Validate checks that value is acceptable for the field
+func (field Field) Validate(value string) bool {
+	switch field.Kind {
+	case FieldBoolean:
+		return value == "0" || value == "1"
+	case FieldCounter:
+		n, err := strconv.Atoi(value)
+		return err == nil && n >= field.Min && n <= field.Max
+	case FieldEnum:
+		for _, option := range field.Options {
+			if value == option {
+				return true
+			}
+		}
+	case FieldText:
+		return len(value) >= field.Min && (field.Max == 0 || len(value) <= field.Max)
+	}
+	return false
+}
+
+// Numeric reports whether values of the field can be averaged and compared
+func (field Field) Numeric() bool {
+	return field.Kind == FieldCounter || field.Kind == FieldBoolean
+}
+
+// Number converts a value of a numeric field into a number.  Returns false if
+// the field isn't numeric or the value couldn't be understood.
+func (field Field) Number(value string) (float64, bool) {
+	if !field.Numeric() {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return float64(n), true
+}
+
+// PointsFor estimates how many points the value of the field earned
+func (field Field) PointsFor(value string) float64 {
+	if field.Kind == FieldEnum {
+		return field.OptionPoints[value]
+	}
+	n, ok := field.Number(value)
+	if !ok {
+		return 0
+	}
+	return n * field.Points
+}
+
+// Season describes the game played during a single year and every field that
+// gets scouted about it.  Fields are recorded for every match, while
+// PitFields describe the robot itself and are recorded in the pits.
+type Season struct {
+	Year      int     `json:"year"`
+	Game      string  `json:"game"`
+	Fields    []Field `json:"fields"`
+	PitFields []Field `json:"pit"`
+}
+
+// Field finds the match field with the given name.  Returns nil if the
+// season has no such field.
+func (season *Season) Field(name string) *Field {
+	return findField(season.Fields, name)
+}
+
+// PitField finds the pit field with the given name.  Returns nil if the
+// season has no such field.
+func (season *Season) PitField(name string) *Field {
+	return findField(season.PitFields, name)
+}
+
+func findField(fields []Field, name string) *Field {
+	for i := range fields {
+		if fields[i].Name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// Points estimates how many points the robot described by the submission
+// scored for its alliance.
+func (season *Season) Points(sub Submission) float64 {
+	points := 0.0
+	for _, field := range season.Fields {
+		points += field.PointsFor(sub.Values[field.Name])
+	}
+	return points
+}
+
+// NumericFields returns only the fields that can be averaged and compared
+func (season *Season) NumericFields() []Field {
+	fields := []Field{}
+	for _, field := range season.Fields {
+		if field.Numeric() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+var (
+	seasons = map[int]*Season{}
+	// seasonDir is the directory the seasons were loaded from
+	seasonDir = "seasons"
+
+	fieldNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+)
+
+// LoadSeasons reads every season file in dir.  Each file must be named after
+// the year it describes (2017.json, for example).  Returns ErrInvalidSeason if
+// any of the files don't describe a usable season.
+func LoadSeasons(dir string) error {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	loaded := map[int]*Season{}
+	for _, name := range names {
+		year, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(name), ".json"))
+		if err != nil {
+			return ErrInvalidSeason
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		season := &Season{}
+		err = json.NewDecoder(f).Decode(season)
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		if season.Year != year || !season.valid() {
+			return ErrInvalidSeason
+		}
+		loaded[year] = season
+	}
+
+	seasons, seasonDir = loaded, dir
+	return nil
+}
+
+// SeasonFile is where LoadSeasons looks for the season of a year, which is
+// what has to be added when GetSeason returns ErrNoSeason
+func SeasonFile(year int) string {
+	return filepath.Join(seasonDir, strconv.Itoa(year)+".json")
+}
+
+// valid checks that the season's match and pit fields are well formed and
+// uniquely named
+func (season *Season) valid() bool {
+	return len(season.Fields) > 0 && validFields(season.Fields) && validFields(season.PitFields)
+}
+
+func validFields(fields []Field) bool {
+	names := map[string]bool{}
+	for _, field := range fields {
+		if !fieldNamePattern.MatchString(field.Name) || names[field.Name] || field.Label == "" {
+			return false
+		}
+		names[field.Name] = true
+
+		switch field.Kind {
+		case FieldCounter, FieldText:
+			if field.Min < 0 || (field.Max != 0 && field.Min > field.Max) {
+				return false
+			}
+			if field.Kind == FieldCounter && field.Max == 0 {
+				return false
+			}
+		case FieldEnum:
+			if len(field.Options) == 0 {
+				return false
+			}
+			for option := range field.OptionPoints {
+				if !field.Validate(option) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// GetSeason retrieves the season for the given year.  Returns ErrNoSeason if
+// no season file was loaded for that year.
+func GetSeason(year int) (*Season, error) {
+	season, ok := seasons[year]
+	if !ok {
+		return nil, ErrNoSeason
+	}
+	return season, nil
+}
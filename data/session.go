@@ -0,0 +1,115 @@
+package data
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// sessionLifetime is how long a session lasts after logging in
+	sessionLifetime = 2 * time.Hour
+	// sessionIdleTimeout is how long a session lasts without being used
+	sessionIdleTimeout = 30 * time.Minute
+	// maxDeviceLength is the longest a session's device label may be
+	maxDeviceLength = 128
+)
+
+// Session is a login of a user on one device
+type Session struct {
+	Id       int64
+	Device   string
+	Created  Timestamp
+	LastSeen Timestamp
+	Expires  Timestamp
+
+	// Current is true for the session the user is looking at the list from
+	Current bool
+}
+
+// startSession creates a session for a user on a device, returning the cookie
+// that identifies it.  Only a hash of the cookie's token is stored, so the
+// sessions table can't be used to log in as anyone.
+func startSession(tx execer, userId int64, device string) (*http.Cookie, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return nil, ErrRandGeneration
+	}
+	token := hex.EncodeToString(buffer)
+	if runes := []rune(device); len(runes) > maxDeviceLength {
+		device = string(runes[:maxDeviceLength])
+	}
+
+	now := Now()
+	// clear out the sessions that ran out while nobody was looking
+	_, err := tx.Exec(`DELETE FROM sessions WHERE user_id=? AND (expires <= ? OR lastseen <= ?)`,
+		userId, now, now.Add(-sessionIdleTimeout))
+	if err == nil {
+		_, err = tx.Exec(`INSERT INTO sessions (token_hash, user_id, device, created, lastseen, expires)
+ VALUES (?, ?, ?, ?, ?, ?)`, hashSessionToken(token), userId, device, now, now, now.Add(sessionLifetime))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.startSession: "+err.Error())
+		return nil, ErrDatabaseUpdate
+	}
+	return createAuthCookie(token, int(sessionLifetime/time.Second)), nil
+}
+
+func hashSessionToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// GetSessions lists the sessions of a user that haven't run out, most
+// recently used first
+func (db DB) GetSessions(user *User) ([]Session, error) {
+	now := Now()
+	rows, err := db.db.Query(`SELECT id, device, created, lastseen, expires
+ FROM sessions
+ WHERE user_id=? AND lastseen > ? AND expires > ?
+ ORDER BY lastseen DESC, id DESC`, user.Id, now.Add(-sessionIdleTimeout), now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		err = rows.Scan(&session.Id, &session.Device, &session.Created, &session.LastSeen, &session.Expires)
+		if err != nil {
+			return nil, err
+		}
+		session.Current = session.Id == user.SessionId
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession logs a user out of one of their sessions
+func (db DB) RevokeSession(user *User, id int64) error {
+	result, err := db.db.Exec(`DELETE FROM sessions WHERE id=? AND user_id=?`, id, user.Id)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.RevokeSession: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	if revoked, err := result.RowsAffected(); err == nil && revoked == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeOtherSessions logs a user out everywhere except the session they're
+// using now
+func (db DB) RevokeOtherSessions(user *User) error {
+	_, err := db.db.Exec(`DELETE FROM sessions WHERE user_id=? AND id<>?`, user.Id, user.SessionId)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.RevokeOtherSessions: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+	return nil
+}
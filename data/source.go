@@ -0,0 +1,279 @@
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source provides the official schedules and results of competitions, such
+// as The Blue Alliance or the FRC Events API.
+type Source interface {
+	// Name identifies the source, such as "tba"
+	Name() string
+	// Events lists every competition of a year without their teams
+	Events(year int) ([]Competition, error)
+	// Event retrieves a single competition without its teams
+	Event(key string) (*Competition, error)
+	// EventTeams lists the number of every team attending a competition
+	EventTeams(key string) ([]int, error)
+	// EventMatches lists every scheduled match of a competition, with their
+	// official scores once they're known
+	EventMatches(key string) ([]Match, error)
+}
+
+// ImportCompetition pulls a competition, its teams and its matches from the
+// source and stores everything that changed.  Importing again is harmless:
+// teams and matches are never duplicated, and scores the source doesn't know
+// yet are never erased.  A competition imported for the first time is synced
+// from the same source from then on.  Competitions of other years belong in
+// other databases, so they're refused.  Keys are stored in lowercase however
+// they're given.
+func (db DB) ImportCompetition(source Source, key string) error {
+	key = strings.ToLower(key)
+	season, _, err := splitEventKey(key)
+	if err != nil {
+		return err
+	}
+	if season != db.year {
+		return ErrCompetitionYear
+	}
+	comp, err := source.Event(key)
+	if err != nil {
+		return err
+	}
+	if comp.Key == "" || comp.Name == "" {
+		return ErrInvalidCompetition
+	}
+	teams, err := source.EventTeams(key)
+	if err != nil {
+		return err
+	}
+	matches, err := source.EventMatches(key)
+	if err != nil {
+		return err
+	}
+
+	existing, err := db.GetCompetition(key)
+	if err != nil && err != ErrCompetitionNotFound {
+		return err
+	}
+	attending := map[int]bool{}
+	if existing != nil {
+		for _, team := range existing.Teams {
+			attending[team] = true
+		}
+	}
+	scheduled, err := db.GetMatches(key)
+	if err != nil {
+		return err
+	}
+	known := map[string]Match{}
+	for _, match := range scheduled {
+		known[matchKey(match.Competition, match.Level, match.Number)] = match
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	if existing == nil {
+		_, err = tx.Exec(
+			`INSERT INTO competitions (event_key, name, location, start_date, end_date, source) VALUES (?, ?, ?, ?, ?, ?)`,
+			key, comp.Name, comp.Location, comp.Start, comp.End, source.Name())
+	} else if existing.Name != comp.Name || existing.Location != comp.Location ||
+		existing.Start.String() != comp.Start.String() || existing.End.String() != comp.End.String() {
+		_, err = tx.Exec(`UPDATE competitions SET name=?, location=?, start_date=?, end_date=? WHERE event_key=?`,
+			comp.Name, comp.Location, comp.Start, comp.End, key)
+	}
+	if err == nil && existing != nil && existing.Source == "" {
+		// a competition entered by hand is synced once it has been imported
+		_, err = tx.Exec(`UPDATE competitions SET source=? WHERE event_key=?`, source.Name(), key)
+	}
+	for _, team := range teams {
+		if err != nil {
+			break
+		}
+		if team > 0 && !attending[team] {
+			_, err = tx.Exec(`INSERT INTO competition_teams (competition, team) VALUES (?, ?)`, key, team)
+			attending[team] = true
+		}
+	}
+	for _, match := range matches {
+		if err != nil {
+			break
+		}
+		match.Competition = key
+		if match.Number <= 0 || len(match.Red) != 3 || len(match.Blue) != 3 {
+			continue // the source has more kinds of match than we keep track of
+		}
+		err = importMatch(tx, match, known)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.ImportCompetition: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+
+	if err = tx.Commit(); err != nil {
+		return ErrDatabaseUpdate
+	}
+	invalidateAnalyses(db.year)
+	return nil
+}
+
+// importMatch stores a match from a source unless it's already stored as it
+// is.  known maps the key of every stored match of the competition to it.
+func importMatch(tx execer, match Match, known map[string]Match) error {
+	old, ok := known[matchKey(match.Competition, match.Level, match.Number)]
+	if !ok {
+		var redScore, blueScore interface{}
+		if match.Scored {
+			redScore, blueScore = match.RedScore, match.BlueScore
+		}
+		_, err := tx.Exec(`INSERT INTO matches
+ (competition, level, match_number, red1, red2, red3, blue1, blue2, blue3, red_score, blue_score)
+ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			match.Competition, match.Level.String(), match.Number,
+			match.Red[0], match.Red[1], match.Red[2], match.Blue[0], match.Blue[1], match.Blue[2],
+			redScore, blueScore)
+		return err
+	}
+
+	sameTeams := len(old.Red) == 3 && len(old.Blue) == 3
+	for i := 0; sameTeams && i < 3; i++ {
+		sameTeams = old.Red[i] == match.Red[i] && old.Blue[i] == match.Blue[i]
+	}
+	if !sameTeams {
+		_, err := tx.Exec(`UPDATE matches SET red1=?, red2=?, red3=?, blue1=?, blue2=?, blue3=?
+ WHERE competition=? AND level=? AND match_number=?`,
+			match.Red[0], match.Red[1], match.Red[2], match.Blue[0], match.Blue[1], match.Blue[2],
+			match.Competition, match.Level.String(), match.Number)
+		if err != nil {
+			return err
+		}
+	}
+	if match.Scored && (!old.Scored || old.RedScore != match.RedScore || old.BlueScore != match.BlueScore) {
+		_, err := tx.Exec(`UPDATE matches SET red_score=?, blue_score=?
+ WHERE competition=? AND level=? AND match_number=?`,
+			match.RedScore, match.BlueScore, match.Competition, match.Level.String(), match.Number)
+		return err
+	}
+	return nil
+}
+
+// execer is the part of a database or transaction that runs statements
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// SyncActiveCompetitions imports every competition that is underway, until
+// the day after it ends, from whichever of the sources is authoritative for
+// it.  Sources are keyed by name; competitions whose source isn't among them
+// are skipped.  It's meant to be called periodically during events so scores
+// show up without anyone typing them in.  Every competition is attempted even
+// if one fails; the first error is returned.
+func (db DB) SyncActiveCompetitions(sources map[string]Source, now time.Time) error {
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+	var first error
+	for _, comp := range competitions {
+		start, end := comp.Start.time, comp.End.time.Add(48*time.Hour)
+		source, ok := sources[comp.Source]
+		if !ok || now.Before(start) || now.After(end) {
+			continue
+		}
+		if err = db.ImportCompetition(source, comp.Key); err != nil {
+			fmt.Fprintln(os.Stderr, "data.SyncActiveCompetitions: "+comp.Key+": "+err.Error())
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// sourceCache remembers the last response to every request a source made so
+// that later requests can be made conditional.  Sources are asked to be
+// polled this way, and it keeps periodic syncs cheap for both sides.
+type sourceCache struct {
+	mutex   sync.Mutex
+	entries map[string]sourceCacheEntry
+}
+
+type sourceCacheEntry struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// getJSON performs a GET request and decodes the JSON response into v.  If
+// an earlier response is cached, the request carries its ETag and
+// Last-Modified date, and a 304 Not Modified answer decodes the cached body.
+func (cache *sourceCache) getJSON(client *http.Client, request *http.Request, v interface{}) error {
+	url := request.URL.String()
+	cache.mutex.Lock()
+	entry, cached := cache.entries[url]
+	cache.mutex.Unlock()
+	if cached {
+		if entry.etag != "" {
+			request.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			request.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && cached:
+		// the cached body is still current
+	case response.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+		entry = sourceCacheEntry{
+			etag:         response.Header.Get("ETag"),
+			lastModified: response.Header.Get("Last-Modified"),
+			body:         body,
+		}
+		cache.mutex.Lock()
+		if cache.entries == nil {
+			cache.entries = map[string]sourceCacheEntry{}
+		}
+		cache.entries[url] = entry
+		cache.mutex.Unlock()
+	case response.StatusCode == http.StatusNotFound:
+		return ErrCompetitionNotFound
+	case response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden:
+		return ErrSourceAccessDenied
+	default:
+		return fmt.Errorf("data: %s answered %s", url, response.Status)
+	}
+	return json.Unmarshal(entry.body, v)
+}
+
+// parseSourceDate reads a date in the form 2006-01-02 as used by sources
+func parseSourceDate(s string) Timestamp {
+	if len(s) > len("2006-01-02") {
+		s = s[:len("2006-01-02")] // some sources include a time of day
+	}
+	t, _ := time.Parse("2006-01-02", s)
+	return Timestamp{time: t}
+}
@@ -0,0 +1,70 @@
+package data
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeAPI stands in for the API of a source, answering every path it has a
+// body for with that body and an ETag that changes along with it
+type fakeAPI struct {
+	t *testing.T
+	// authorized checks the credentials of a request
+	authorized func(request *http.Request) bool
+
+	mutex       sync.Mutex
+	bodies      map[string]string
+	versions    map[string]int
+	requests    int
+	notModified int
+}
+
+func newFakeAPI(t *testing.T, authorized func(request *http.Request) bool) (*fakeAPI, *httptest.Server) {
+	api := &fakeAPI{t: t, authorized: authorized, bodies: map[string]string{}, versions: map[string]int{}}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+// set changes what the API answers for a path
+func (api *fakeAPI) set(path, body string) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	api.bodies[path] = body
+	api.versions[path]++
+}
+
+func (api *fakeAPI) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	api.requests++
+	if !api.authorized(request) {
+		http.Error(writer, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body, ok := api.bodies[request.URL.Path]
+	if !ok {
+		http.NotFound(writer, request)
+		return
+	}
+	etag := fmt.Sprintf(`"%s-%d"`, request.URL.Path, api.versions[request.URL.Path])
+	if request.Header.Get("If-None-Match") == etag {
+		api.notModified++
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writer.Header().Set("ETag", etag)
+	writer.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(writer, body)
+}
+
+// counts returns how many requests the API has answered, and how many of
+// them it answered with 304 Not Modified
+func (api *fakeAPI) counts() (int, int) {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	return api.requests, api.notModified
+}
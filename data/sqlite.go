@@ -0,0 +1,126 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	_ "modernc.org/sqlite" // the init function registers a sql driver
+)
+
+// SQLite is a Backend that keeps every year in a file of its own, using a
+// SQLite implementation written in pure Go.  It needs no database server,
+// which suits a single laptop in the pits.
+type SQLite struct {
+	dir string
+
+	mutex sync.Mutex
+	ready map[string]bool // the databases migrated since the program started
+}
+
+// NewSQLite creates a backend keeping its databases in the directory dir
+func NewSQLite(dir string) *SQLite {
+	return &SQLite{dir: dir, ready: map[string]bool{}}
+}
+
+// Name identifies SQLite as a backend
+func (backend *SQLite) Name() string {
+	return "sqlite"
+}
+
+// Dialect is SQLite's own
+func (backend *SQLite) Dialect() string {
+	return "sqlite"
+}
+
+// Provision makes sure the directory holding the databases exists.  The file
+// of a year is created as soon as it's opened.
+func (backend *SQLite) Provision(year int) error {
+	return os.MkdirAll(backend.dir, 0755)
+}
+
+// Open connects to the database of a year.  Since nobody has to set up a
+// database server, the file and its tables are created and migrated the
+// first time each year is opened.
+func (backend *SQLite) Open(year int) (*sql.DB, error) {
+	path := filepath.Join(backend.dir, fmt.Sprintf("scouting%d.db", year))
+	// other connections may be writing, so wait for them instead of failing
+	return backend.open("file:" + path + "?_pragma=busy_timeout(5000)")
+}
+
+func (backend *SQLite) open(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	if !backend.ready[dsn] {
+		if _, err = migrate(db, backend.Dialect(), false); err != nil {
+			db.Close()
+			return nil, err
+		}
+		backend.ready[dsn] = true
+	}
+	return db, nil
+}
+
+// memories numbers every Memory backend so they don't share databases
+var memories int64
+
+// Memory is a Backend that keeps everything in SQLite databases held in
+// memory, which are gone once the program exits.  It's meant for tests and
+// for trying the scouting system out.
+type Memory struct {
+	id     int64
+	sqlite *SQLite
+
+	mutex sync.Mutex
+	// keep holds a connection to every database open, since an in-memory
+	// database is thrown away when its last connection closes
+	keep map[int]*sql.DB
+}
+
+// NewMemory creates a backend whose databases are empty to begin with
+func NewMemory() *Memory {
+	return &Memory{id: atomic.AddInt64(&memories, 1), sqlite: NewSQLite(""), keep: map[int]*sql.DB{}}
+}
+
+// Name identifies memory as a backend
+func (backend *Memory) Name() string {
+	return "memory"
+}
+
+// Dialect is SQLite's, since the databases are SQLite's
+func (backend *Memory) Dialect() string {
+	return "sqlite"
+}
+
+// Provision has nothing to do, since databases are created when opened
+func (backend *Memory) Provision(year int) error {
+	return nil
+}
+
+// Open connects to the database of a year, creating it the first time
+func (backend *Memory) Open(year int) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:scout-memory%d-%d?mode=memory&cache=shared", backend.id, year)
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+	if backend.keep[year] == nil {
+		keep, err := backend.sqlite.open(dsn)
+		if err != nil {
+			return nil, err
+		}
+		keep.SetMaxIdleConns(1)
+		keep.SetConnMaxLifetime(0)
+		if err = keep.Ping(); err != nil {
+			keep.Close()
+			return nil, err
+		}
+		backend.keep[year] = keep
+	}
+	return sql.Open("sqlite", dsn)
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"math"
+	"sort"
+)
+
+// FieldStats summarizes every value recorded for a single numeric field
+type FieldStats struct {
+	Field  Field
+	Count  int
+	Mean   float64
+	Median float64
+	StdDev float64
+	Min    float64
+	Max    float64
+
+	// Consistency is one minus the coefficient of variation, clamped between
+	// zero and one.  A team that scores exactly the same every match has a
+	// consistency of one.
+	Consistency float64
+
+	// Trend is how much the value changes from one submission to the next,
+	// found by fitting a line through the values in the order they were
+	// recorded.
+	Trend float64
+}
+
+// TeamSummary holds the aggregate performance of a single team
+type TeamSummary struct {
+	Number      int
+	Submissions int
+
+	// Stats maps the name of every numeric field to its summary
+	Stats map[string]FieldStats
+
+	// Points summarizes the points the team was estimated to score in each
+	// submission
+	Points FieldStats
+}
+
+// SummarizeField computes the statistics of a field over the submissions,
+// which should be ordered oldest first.  Submissions without a value for the
+// field are skipped.
+func SummarizeField(field Field, subs []Submission) FieldStats {
+	values := make([]float64, 0, len(subs))
+	for _, sub := range subs {
+		if n, ok := field.Number(sub.Values[field.Name]); ok {
+			values = append(values, n)
+		}
+	}
+	return summarize(field, values)
+}
+
+// summarize computes the statistics of the values, which should be in the
+// order they were recorded
+func summarize(field Field, values []float64) FieldStats {
+	stats := FieldStats{Field: field, Count: len(values)}
+	if len(values) == 0 {
+		return stats
+	}
+
+	stats.Min, stats.Max = values[0], values[0]
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+		if v < stats.Min {
+			stats.Min = v
+		}
+		if v > stats.Max {
+			stats.Max = v
+		}
+	}
+	stats.Mean = sum / float64(len(values))
+	stats.Trend = slope(values)
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	if half := len(sorted) / 2; len(sorted)%2 == 1 {
+		stats.Median = sorted[half]
+	} else {
+		stats.Median = (sorted[half-1] + sorted[half]) / 2
+	}
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - stats.Mean) * (v - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(values)))
+
+	switch {
+	case stats.StdDev == 0:
+		stats.Consistency = 1
+	case stats.Mean <= 0:
+		stats.Consistency = 0
+	default:
+		stats.Consistency = math.Max(0, 1-stats.StdDev/stats.Mean)
+	}
+	return stats
+}
+
+// slope finds the least-squares slope of the values against their indices
+func slope(values []float64) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+	meanX := (n - 1) / 2
+	meanY := 0.0
+	for _, v := range values {
+		meanY += v
+	}
+	meanY /= n
+
+	var num, den float64
+	for i, v := range values {
+		dx := float64(i) - meanX
+		num += dx * (v - meanY)
+		den += dx * dx
+	}
+	return num / den
+}
+
+// SummarizeTeams groups the submissions by team and summarizes every numeric
+// field of the season for each of them.  The summaries are ordered by team
+// number.
+func SummarizeTeams(season *Season, subs []Submission) []TeamSummary {
+	byTeam := map[int][]Submission{}
+	for _, sub := range subs {
+		byTeam[sub.Team] = append(byTeam[sub.Team], sub)
+	}
+
+	summaries := make([]TeamSummary, 0, len(byTeam))
+	for team, teamSubs := range byTeam {
+		summaries = append(summaries, SummarizeTeam(season, team, teamSubs))
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Number < summaries[j].Number
+	})
+	return summaries
+}
+
+// SummarizeTeam summarizes every numeric field of the season over the
+// submissions made about a single team.
+func SummarizeTeam(season *Season, team int, subs []Submission) TeamSummary {
+	summary := TeamSummary{
+		Number:      team,
+		Submissions: len(subs),
+		Stats:       map[string]FieldStats{},
+	}
+	for _, field := range season.NumericFields() {
+		summary.Stats[field.Name] = SummarizeField(field, subs)
+	}
+
+	points := make([]float64, len(subs))
+	for i, sub := range subs {
+		points[i] = season.Points(sub)
+	}
+	summary.Points = summarize(Field{Name: "points", Label: "Points", Kind: FieldCounter}, points)
+	return summary
+}
@@ -0,0 +1,314 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Alliance is one of the two sides competing in a match
+type Alliance int
+
+const (
+	// Red is the red alliance
+	Red Alliance = iota
+	// Blue is the blue alliance
+	Blue
+)
+
+// String returns the name of the alliance as it is stored in the database
+func (alliance Alliance) String() string {
+	if alliance == Blue {
+		return "blue"
+	}
+	return "red"
+}
+
+// ParseAlliance converts the name of an alliance back into an Alliance.
+// Returns ErrInvalidAlliance if the name isn't recognized.
+func ParseAlliance(name string) (Alliance, error) {
+	switch name {
+	case "red":
+		return Red, nil
+	case "blue":
+		return Blue, nil
+	}
+	return Red, ErrInvalidAlliance
+}
+
+// Submission represents what a single scout recorded about a single robot
+// during a single match.
+type Submission struct {
+	Id          int64
+	Competition string // the event key of the competition the match was part of
+	Level       MatchLevel
+	Match       int
+	Team        int
+	Alliance    Alliance
+	ScoutId     int64
+	ScoutName   string
+	Time        Timestamp
+
+	// ClientId is the id given to the submission by a scout's device when it
+	// was made offline, so that syncing it again doesn't store it twice.  It is
+	// empty for submissions posted directly.
+	ClientId string
+
+	// Values maps the name of every game field to the value recorded for it
+	Values map[string]string
+}
+
+// Validate checks that the submission is complete and that all of its values
+// fit the fields of the season.  Returns nil if the submission is acceptable.
+func (sub Submission) Validate(season *Season) error {
+	if sub.Competition == "" {
+		return ErrInvalidCompetition
+	}
+	if sub.Match <= 0 {
+		return ErrInvalidMatch
+	}
+	if sub.Team <= 0 {
+		return ErrInvalidTeam
+	}
+	if sub.Alliance != Red && sub.Alliance != Blue {
+		return ErrInvalidAlliance
+	}
+	for _, field := range season.Fields {
+		if !field.Validate(sub.Values[field.Name]) {
+			return ErrInvalidField
+		}
+	}
+	if len(sub.Values) != len(season.Fields) {
+		return ErrInvalidField // there were values for fields that don't exist
+	}
+	return nil
+}
+
+// InsertSubmission validates the submission against the season for the
+// database's year and stores it.  Every field value is kept as its own row
+// keyed by the field's name, so a new season never requires new columns.  On
+// success, the Id and Time of the submission are filled in.  Returns
+// ErrInvalidCompetition unless the submission's competition is stored.
+func (db DB) InsertSubmission(sub *Submission) error {
+	season, err := GetSeason(db.year)
+	if err != nil {
+		return err
+	}
+	if err = sub.Validate(season); err != nil {
+		return err
+	}
+	if err = db.checkCompetition(sub.Competition); err != nil {
+		return err
+	}
+	sub.Time = Now()
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // has no effect once committed
+
+	id, err := insertSubmission(tx, sub)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.InsertSubmission: "+err.Error())
+		return ErrDatabaseUpdate
+	}
+
+	if err = tx.Commit(); err != nil {
+		return ErrDatabaseUpdate
+	}
+	invalidateAnalyses(db.year)
+	sub.Id = id
+	return nil
+}
+
+// insertSubmission stores a submission that has already been validated,
+// along with all of its values, and returns its id
+func insertSubmission(tx execer, sub *Submission) (int64, error) {
+	result, err := tx.Exec(
+		`INSERT INTO submissions (competition, level, match_number, team, alliance, scout, created, client_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sub.Competition, sub.Level.String(), sub.Match, sub.Team, sub.Alliance.String(), sub.ScoutId, sub.Time,
+		sql.NullString{String: sub.ClientId, Valid: sub.ClientId != ""})
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	for name, value := range sub.Values {
+		_, err = tx.Exec(`INSERT INTO submission_values (submission, field, value) VALUES (?, ?, ?)`, id, name, value)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// SubmissionFilter narrows down which submissions are retrieved.  Zero values
+// match everything.
+type SubmissionFilter struct {
+	Competition string
+	Team        int
+	Match       int
+	Scout       string // the username of the scout
+
+	// Offset and Limit select a single page of the results.  A Limit of zero
+	// retrieves every result.
+	Offset int
+	Limit  int
+}
+
+// where builds the WHERE clause selecting the submissions matched by the
+// filter along with its arguments
+func (filter SubmissionFilter) where() (string, []interface{}) {
+	clause := " WHERE 1=1"
+	args := []interface{}{}
+	if filter.Competition != "" {
+		clause += " AND s.competition=?"
+		args = append(args, filter.Competition)
+	}
+	if filter.Team > 0 {
+		clause += " AND s.team=?"
+		args = append(args, filter.Team)
+	}
+	if filter.Match > 0 {
+		clause += " AND s.match_number=?"
+		args = append(args, filter.Match)
+	}
+	if filter.Scout != "" {
+		clause += " AND u.username=?"
+		args = append(args, filter.Scout)
+	}
+	return clause, args
+}
+
+// CountSubmissions counts every submission matching the filter, ignoring its
+// Offset and Limit.
+func (db DB) CountSubmissions(filter SubmissionFilter) (int, error) {
+	where, args := filter.where()
+	var count int
+	err := db.db.QueryRow(`SELECT COUNT(*) FROM submissions s LEFT JOIN users u ON u.id=s.scout`+where, args...).Scan(&count)
+	return count, err
+}
+
+// GetSubmission retrieves a single submission by its id.  Returns
+// ErrSubmissionNotFound if there is no such submission.
+func (db DB) GetSubmission(id int64) (*Submission, error) {
+	subs, err := db.querySubmissions(` WHERE s.id=?`, []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, ErrSubmissionNotFound
+	}
+	return &subs[0], nil
+}
+
+// GetSubmissions retrieves every submission matching the filter, oldest
+// first, with all of their values filled in.
+func (db DB) GetSubmissions(filter SubmissionFilter) ([]Submission, error) {
+	where, args := filter.where()
+	where += " ORDER BY s.created, s.id"
+	if filter.Limit > 0 {
+		where += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	return db.querySubmissions(where, args)
+}
+
+// querySubmissions retrieves the submissions selected by the given WHERE
+// clause, which may also order and limit them.
+func (db DB) querySubmissions(where string, args []interface{}) ([]Submission, error) {
+	query := `SELECT s.id, s.competition, s.level, s.match_number, s.team, s.alliance, s.scout, u.username, s.created, s.client_id
+ FROM submissions s LEFT JOIN users u ON u.id=s.scout` + where
+
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.querySubmissions: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []Submission{}
+	indices := map[int64]int{}
+	for rows.Next() {
+		var (
+			sub             Submission
+			level, alliance string
+			scoutName       sql.NullString
+			clientId        sql.NullString
+		)
+		err = rows.Scan(&sub.Id, &sub.Competition, &level, &sub.Match, &sub.Team, &alliance, &sub.ScoutId, &scoutName,
+			&sub.Time, &clientId)
+		if err != nil {
+			return nil, err
+		}
+		sub.Level, _ = ParseMatchLevel(level)
+		sub.Alliance, _ = ParseAlliance(alliance)
+		sub.ScoutName = scoutName.String
+		sub.ClientId = clientId.String
+		sub.Values = map[string]string{}
+		indices[sub.Id] = len(subs)
+		subs = append(subs, sub)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, db.fillSubmissionValues(subs, indices)
+}
+
+// submissionValuesBatch is the most submissions whose values are loaded by a
+// single query, which keeps the number of placeholders well under what any
+// backend allows however many submissions there are
+const submissionValuesBatch = 500
+
+// fillSubmissionValues loads the field values of every submission.  indices
+// maps submission ids to their position in subs.
+func (db DB) fillSubmissionValues(subs []Submission, indices map[int64]int) error {
+	for start := 0; start < len(subs); start += submissionValuesBatch {
+		end := start + submissionValuesBatch
+		if end > len(subs) {
+			end = len(subs)
+		}
+		if err := db.fillSubmissionValuesBatch(subs, indices, subs[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillSubmissionValuesBatch loads the field values of the submissions in
+// batch, which is part of subs
+func (db DB) fillSubmissionValuesBatch(subs []Submission, indices map[int64]int, batch []Submission) error {
+	placeholders := make([]string, len(batch))
+	args := make([]interface{}, len(batch))
+	for i, sub := range batch {
+		placeholders[i] = "?"
+		args[i] = sub.Id
+	}
+	rows, err := db.db.Query(
+		`SELECT submission, field, value FROM submission_values WHERE submission IN (`+strings.Join(placeholders, ",")+`)`,
+		args...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.fillSubmissionValues: "+err.Error())
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id           int64
+			field, value string
+		)
+		if err = rows.Scan(&id, &field, &value); err != nil {
+			return err
+		}
+		if i, ok := indices[id]; ok {
+			subs[i].Values[field] = value
+		}
+	}
+	return rows.Err()
+}
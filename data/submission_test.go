@@ -0,0 +1,87 @@
+package data
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestGetSubmissionsInBatches(t *testing.T) {
+	db := newTestDB(t)
+	addTestCompetition(t, db)
+	count := 2*submissionValuesBatch + 7
+	for i := 0; i < count; i++ {
+		sub := testSubmission()
+		sub.ClientId, sub.Match = "", i+1
+		sub.Values["scale"] = strconv.Itoa(i % 20)
+		if err := db.InsertSubmission(&sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	subs, err := db.GetSubmissions(SubmissionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != count {
+		t.Fatalf("GetSubmissions found %d submissions, want %d", len(subs), count)
+	}
+	for _, sub := range subs {
+		if len(sub.Values) != len(testSubmission().Values) || sub.Values["scale"] != strconv.Itoa((sub.Match-1)%20) {
+			t.Fatalf("submission for match %d has values %v", sub.Match, sub.Values)
+		}
+	}
+}
+
+func TestValidateSubmission(t *testing.T) {
+	tests := []struct {
+		change func(sub *Submission)
+		want   error
+	}{
+		{func(sub *Submission) {}, nil},
+		{func(sub *Submission) { sub.Competition = "" }, ErrInvalidCompetition},
+		{func(sub *Submission) { sub.Match = 0 }, ErrInvalidMatch},
+		{func(sub *Submission) { sub.Team = -4476 }, ErrInvalidTeam},
+		{func(sub *Submission) { sub.Alliance = Alliance(2) }, ErrInvalidAlliance},
+		{func(sub *Submission) { sub.Values["vault"] = "10" }, ErrInvalidField},
+		{func(sub *Submission) { delete(sub.Values, "comments") }, ErrInvalidField},
+		{func(sub *Submission) { sub.Values["teleop-ramp"] = "1" }, ErrInvalidField},
+	}
+	for i, test := range tests {
+		sub := testSubmission()
+		test.change(&sub)
+		if err := sub.Validate(mustSeason()); err != test.want {
+			t.Errorf("test %d: Validate(%+v) = %v, want %v", i, sub, err, test.want)
+		}
+	}
+}
+
+func TestInsertSubmission(t *testing.T) {
+	db := newTestDB(t)
+	scout := addTestUser(t, db, "scout", "a password", false)
+	sub := testSubmission()
+	sub.ScoutId = scout
+	if err := db.InsertSubmission(&sub); err != ErrInvalidCompetition {
+		t.Errorf("a submission for a competition that isn't stored = %v, want ErrInvalidCompetition", err)
+	}
+	if count, err := db.CountSubmissions(SubmissionFilter{}); err != nil || count != 0 {
+		t.Errorf("%d submissions were stored, %v", count, err)
+	}
+
+	addTestCompetition(t, db)
+	if err := db.InsertSubmission(&sub); err != nil {
+		t.Fatal(err)
+	}
+	if sub.Id == 0 || sub.Time.String() == (Timestamp{}).String() {
+		t.Errorf("InsertSubmission left %+v", sub)
+	}
+	stored, err := db.GetSubmission(sub.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sub
+	want.ScoutName, want.Time = "scout", stored.Time // only whole seconds are stored
+	if !reflect.DeepEqual(*stored, want) || stored.Time.String() != sub.Time.String() {
+		t.Errorf("GetSubmission = %+v, want %+v", *stored, want)
+	}
+}
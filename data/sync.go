@@ -0,0 +1,149 @@
+package data
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxClientIdLength is the longest client id the submissions table can hold
+const maxClientIdLength = 64
+
+// SyncStatus is what became of a submission sent by an offline client
+type SyncStatus string
+
+const (
+	// SyncCreated means the submission was stored for the first time
+	SyncCreated SyncStatus = "created"
+	// SyncDuplicate means the submission had already been stored by an
+	// earlier sync, so nothing changed
+	SyncDuplicate SyncStatus = "duplicate"
+	// SyncConflict means a different submission was already stored under the
+	// same client id, either by another scout or because it has since been
+	// edited
+	SyncConflict SyncStatus = "conflict"
+	// SyncInvalid means the submission could never be stored as it is
+	SyncInvalid SyncStatus = "invalid"
+)
+
+// SyncResult reports what happened to a single synced submission.  Id is the
+// stored submission the client id refers to, if there is one.  Err explains
+// why an invalid submission was rejected.
+type SyncResult struct {
+	ClientId string
+	Status   SyncStatus
+	Id       int64
+	Err      error
+}
+
+// NewClientId makes up a random client id for a submission that will be
+// stored somewhere other than where it was made
+func NewClientId() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", ErrRandGeneration
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// ValidClientId checks that a client id fits in the database and is made of
+// nothing but letters, digits, dashes and underscores, which covers the UUIDs
+// and random ids clients make up
+func ValidClientId(clientId string) bool {
+	if clientId == "" || len(clientId) > maxClientIdLength {
+		return false
+	}
+	return strings.Trim(clientId, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") == ""
+}
+
+// GetSubmissionByClientId retrieves the submission a client gave the id.
+// Returns ErrSubmissionNotFound if no submission has it.
+func (db DB) GetSubmissionByClientId(clientId string) (*Submission, error) {
+	subs, err := db.querySubmissions(` WHERE s.client_id=?`, []interface{}{clientId})
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, ErrSubmissionNotFound
+	}
+	return &subs[0], nil
+}
+
+// SyncSubmission stores a submission the user made while offline.  Syncing
+// the same submission any number of times stores it once, so clients may
+// safely retry until they hear back.  Only an error unrelated to the
+// submission itself is returned; everything else is reported in the result.
+func (db DB) SyncSubmission(user *User, sub *Submission) (SyncResult, error) {
+	if user == nil {
+		return SyncResult{}, ErrAccessDenied
+	}
+	sub.ScoutId = user.Id
+	return db.storeOnce(sub)
+}
+
+// ImportSubmission stores a submission carried over from another scout's
+// device, such as by QR code, keeping the scout who made it.  It is
+// idempotent in the same way as SyncSubmission.  Only strategists may import
+// submissions, since they vouch for the scout they name.
+func (db DB) ImportSubmission(user *User, sub *Submission) (SyncResult, error) {
+	if !user.CanStrategize() {
+		return SyncResult{}, ErrAccessDenied
+	}
+	return db.storeOnce(sub)
+}
+
+// storeOnce stores a submission unless one with the same client id already
+// exists
+func (db DB) storeOnce(sub *Submission) (SyncResult, error) {
+	result := SyncResult{ClientId: sub.ClientId}
+	if !ValidClientId(sub.ClientId) {
+		result.Status, result.Err = SyncInvalid, ErrInvalidClientId
+		return result, nil
+	}
+
+	existing, err := db.GetSubmissionByClientId(sub.ClientId)
+	if err == ErrSubmissionNotFound {
+		err = db.InsertSubmission(sub)
+		switch err {
+		case nil:
+			result.Status, result.Id = SyncCreated, sub.Id
+			return result, nil
+		case ErrDatabaseUpdate:
+			// another sync of the same submission may have just stored it
+			existing, err = db.GetSubmissionByClientId(sub.ClientId)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "data.storeOnce: "+err.Error())
+				return result, ErrDatabaseUpdate
+			}
+		default:
+			result.Status, result.Err = SyncInvalid, err
+			return result, nil
+		}
+	} else if err != nil {
+		return result, err
+	}
+
+	result.Id = existing.Id
+	if existing.ScoutId == sub.ScoutId && sameSubmission(*existing, *sub) {
+		result.Status = SyncDuplicate
+	} else {
+		result.Status = SyncConflict
+	}
+	return result, nil
+}
+
+// sameSubmission reports whether two submissions recorded the same thing
+func sameSubmission(a, b Submission) bool {
+	if a.Competition != b.Competition || a.Level != b.Level || a.Match != b.Match ||
+		a.Team != b.Team || a.Alliance != b.Alliance || len(a.Values) != len(b.Values) {
+		return false
+	}
+	for name, value := range a.Values {
+		if other, ok := b.Values[name]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidClientId(t *testing.T) {
+	tests := []struct {
+		clientId string
+		valid    bool
+	}{
+		{"", false},
+		{"1b4e28ba-2fa1-11d2-883f-0016d3cca427", true},
+		{"lq2x9k1c-0.4fzyt", false},
+		{"lq2x9k1c-4fzyt", true},
+		{"snake_case_too", true},
+		{strings.Repeat("a", maxClientIdLength), true},
+		{strings.Repeat("a", maxClientIdLength+1), false},
+		{"with space", false},
+		{"quote'", false},
+		{"ünïcödé", false},
+		{"nul\x00", false},
+	}
+	for _, test := range tests {
+		if valid := ValidClientId(test.clientId); valid != test.valid {
+			t.Errorf("ValidClientId(%q) = %v, want %v", test.clientId, valid, test.valid)
+		}
+	}
+}
+
+func TestSyncSubmission(t *testing.T) {
+	db := newTestDB(t)
+	addTestCompetition(t, db)
+	scout := &User{Id: addTestUser(t, db, "scout", "a password", false), Username: "scout"}
+	other := &User{Id: addTestUser(t, db, "other", "a password", false), Username: "other"}
+
+	for _, clientId := range []string{"", strings.Repeat("x", maxClientIdLength+1), "'; DROP TABLE submissions; --"} {
+		sub := testSubmission()
+		sub.ClientId = clientId
+		result, err := db.SyncSubmission(scout, &sub)
+		if err != nil || result.Status != SyncInvalid || result.Err != ErrInvalidClientId {
+			t.Errorf("syncing with the client id %q = %+v, %v", clientId, result, err)
+		}
+	}
+
+	sub := testSubmission()
+	result, err := db.SyncSubmission(scout, &sub)
+	if err != nil || result.Status != SyncCreated {
+		t.Fatalf("SyncSubmission = %+v, %v", result, err)
+	}
+	edited := testSubmission()
+	edited.Values["scale"] = "12"
+	if result, err = db.SyncSubmission(scout, &edited); err != nil || result.Status != SyncConflict || result.Id != sub.Id {
+		t.Errorf("syncing an edited submission = %+v, %v", result, err)
+	}
+	stolen := testSubmission()
+	if result, err = db.SyncSubmission(other, &stolen); err != nil || result.Status != SyncConflict {
+		t.Errorf("syncing another scout's client id = %+v, %v", result, err)
+	}
+	invalid := testSubmission()
+	invalid.ClientId, invalid.Team = "another-id", 0
+	if result, err = db.SyncSubmission(scout, &invalid); err != nil || result.Status != SyncInvalid || result.Err != ErrInvalidTeam {
+		t.Errorf("syncing an invalid submission = %+v, %v", result, err)
+	}
+}
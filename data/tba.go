@@ -0,0 +1,194 @@
+package data
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TBABaseURL is where version 3 of The Blue Alliance API is served
+const TBABaseURL = "https://www.thebluealliance.com/api/v3"
+
+// TBA is a Source backed by The Blue Alliance API.  Every request carries
+// the read key of the account it belongs to.
+type TBA struct {
+	baseURL string
+	authKey string
+	client  *http.Client
+	cache   sourceCache
+}
+
+// NewTBA creates a client for The Blue Alliance API served at baseURL, which
+// is normally TBABaseURL but may point anywhere that answers the same way.
+func NewTBA(baseURL, authKey string) *TBA {
+	return &TBA{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		authKey: authKey,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name identifies The Blue Alliance as a source
+func (tba *TBA) Name() string {
+	return "tba"
+}
+
+// tbaEvent is the simple form of an event in the API
+type tbaEvent struct {
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	City      string `json:"city"`
+	StateProv string `json:"state_prov"`
+	Country   string `json:"country"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+func (event tbaEvent) competition() Competition {
+	location := []string{}
+	for _, part := range []string{event.City, event.StateProv, event.Country} {
+		if part != "" {
+			location = append(location, part)
+		}
+	}
+	return Competition{
+		Key:      event.Key,
+		Name:     event.Name,
+		Location: strings.Join(location, ", "),
+		Start:    parseSourceDate(event.StartDate),
+		End:      parseSourceDate(event.EndDate),
+	}
+}
+
+// tbaMatch is the simple form of a match in the API
+type tbaMatch struct {
+	CompLevel   string `json:"comp_level"`
+	SetNumber   int    `json:"set_number"`
+	MatchNumber int    `json:"match_number"`
+	Alliances   struct {
+		Red  tbaAlliance `json:"red"`
+		Blue tbaAlliance `json:"blue"`
+	} `json:"alliances"`
+}
+
+type tbaAlliance struct {
+	Score    int      `json:"score"`
+	TeamKeys []string `json:"team_keys"`
+}
+
+// tbaPlayoffLevels orders the playoff rounds of the API
+var tbaPlayoffLevels = map[string]int{"ef": 1, "qf": 2, "sf": 3, "f": 4}
+
+func (tba *TBA) get(path string, v interface{}) error {
+	request, err := http.NewRequest("GET", tba.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-TBA-Auth-Key", tba.authKey)
+	return tba.cache.getJSON(tba.client, request, v)
+}
+
+// Events lists every competition of a year
+func (tba *TBA) Events(year int) ([]Competition, error) {
+	var events []tbaEvent
+	if err := tba.get("/events/"+strconv.Itoa(year)+"/simple", &events); err != nil {
+		return nil, err
+	}
+	competitions := make([]Competition, 0, len(events))
+	for _, event := range events {
+		competitions = append(competitions, event.competition())
+	}
+	sort.Slice(competitions, func(i, j int) bool {
+		return competitions[i].Start.time.Before(competitions[j].Start.time)
+	})
+	return competitions, nil
+}
+
+// Event retrieves a single competition
+func (tba *TBA) Event(key string) (*Competition, error) {
+	var event tbaEvent
+	if err := tba.get("/event/"+key+"/simple", &event); err != nil {
+		return nil, err
+	}
+	comp := event.competition()
+	return &comp, nil
+}
+
+// EventTeams lists the number of every team attending a competition
+func (tba *TBA) EventTeams(key string) ([]int, error) {
+	var keys []string
+	if err := tba.get("/event/"+key+"/teams/keys", &keys); err != nil {
+		return nil, err
+	}
+	teams := make([]int, 0, len(keys))
+	for _, key := range keys {
+		if team := tbaTeamNumber(key); team > 0 {
+			teams = append(teams, team)
+		}
+	}
+	sort.Ints(teams)
+	return teams, nil
+}
+
+// EventMatches lists every match of a competition.  The API numbers playoff
+// matches within their round and set, so they are numbered here by round,
+// set and match.  Scheduled times aren't used: matches that aren't scheduled
+// yet have none, and numbering by time would renumber every later match once
+// they got one.
+func (tba *TBA) EventMatches(key string) ([]Match, error) {
+	var apiMatches []tbaMatch
+	if err := tba.get("/event/"+key+"/matches/simple", &apiMatches); err != nil {
+		return nil, err
+	}
+
+	playoffs := []tbaMatch{}
+	matches := []Match{}
+	for _, apiMatch := range apiMatches {
+		if apiMatch.CompLevel == "qm" {
+			matches = append(matches, apiMatch.match(Qualification, apiMatch.MatchNumber))
+		} else if tbaPlayoffLevels[apiMatch.CompLevel] > 0 {
+			playoffs = append(playoffs, apiMatch)
+		}
+	}
+	sort.Slice(playoffs, func(i, j int) bool {
+		a, b := playoffs[i], playoffs[j]
+		if a.CompLevel != b.CompLevel {
+			return tbaPlayoffLevels[a.CompLevel] < tbaPlayoffLevels[b.CompLevel]
+		}
+		if a.SetNumber != b.SetNumber {
+			return a.SetNumber < b.SetNumber
+		}
+		return a.MatchNumber < b.MatchNumber
+	})
+	for i, apiMatch := range playoffs {
+		matches = append(matches, apiMatch.match(Playoff, i+1))
+	}
+	return matches, nil
+}
+
+func (apiMatch tbaMatch) match(level MatchLevel, number int) Match {
+	match := Match{Level: level, Number: number}
+	for _, key := range apiMatch.Alliances.Red.TeamKeys {
+		match.Red = append(match.Red, tbaTeamNumber(key))
+	}
+	for _, key := range apiMatch.Alliances.Blue.TeamKeys {
+		match.Blue = append(match.Blue, tbaTeamNumber(key))
+	}
+	// unplayed matches are scored -1
+	if apiMatch.Alliances.Red.Score >= 0 && apiMatch.Alliances.Blue.Score >= 0 {
+		match.Scored = true
+		match.RedScore, match.BlueScore = apiMatch.Alliances.Red.Score, apiMatch.Alliances.Blue.Score
+	}
+	return match
+}
+
+// tbaTeamNumber converts a team key such as frc254 into its number
+func tbaTeamNumber(key string) int {
+	team, err := strconv.Atoi(strings.TrimPrefix(key, "frc"))
+	if err != nil {
+		return 0
+	}
+	return team
+}
@@ -0,0 +1,179 @@
+package data
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const tbaTestKey = "the read key"
+
+func newFakeTBA(t *testing.T) (*fakeAPI, *TBA) {
+	api, server := newFakeAPI(t, func(request *http.Request) bool {
+		return request.Header.Get("X-TBA-Auth-Key") == tbaTestKey
+	})
+	api.set("/api/v3/event/2018casj/simple", `{
+		"key": "2018casj", "name": "Silicon Valley Regional", "city": "San Jose", "state_prov": "CA",
+		"country": "USA", "start_date": "2018-03-28", "end_date": "2018-03-31"
+	}`)
+	api.set("/api/v3/event/2018casj/teams/keys", `["frc4476", "frc254", "frc971", "bogus"]`)
+	api.set("/api/v3/event/2018casj/matches/simple", `[
+		{"comp_level": "sf", "set_number": 1, "match_number": 1, "time": null,
+			"alliances": {"red": {"score": -1, "team_keys": ["frc1", "frc2", "frc3"]},
+				"blue": {"score": -1, "team_keys": ["frc4", "frc5", "frc6"]}}},
+		{"comp_level": "qf", "set_number": 2, "match_number": 1, "time": 1522540000,
+			"alliances": {"red": {"score": 200, "team_keys": ["frc7", "frc8", "frc9"]},
+				"blue": {"score": 150, "team_keys": ["frc10", "frc11", "frc12"]}}},
+		{"comp_level": "qm", "set_number": 1, "match_number": 2, "time": 1522450000,
+			"alliances": {"red": {"score": -1, "team_keys": ["frc254", "frc971", "frc4476"]},
+				"blue": {"score": -1, "team_keys": ["frc1", "frc2", "frc3"]}}},
+		{"comp_level": "qf", "set_number": 1, "match_number": 2, "time": 1522560000,
+			"alliances": {"red": {"score": 90, "team_keys": ["frc13", "frc14", "frc15"]},
+				"blue": {"score": 95, "team_keys": ["frc16", "frc17", "frc18"]}}},
+		{"comp_level": "qm", "set_number": 1, "match_number": 1, "time": 1522440000,
+			"alliances": {"red": {"score": 310, "team_keys": ["frc4476", "frc254", "frc971"]},
+				"blue": {"score": 120, "team_keys": ["frc1", "frc2", "frc3"]}}},
+		{"comp_level": "qf", "set_number": 1, "match_number": 1, "time": 1522530000,
+			"alliances": {"red": {"score": 80, "team_keys": ["frc13", "frc14", "frc15"]},
+				"blue": {"score": 70, "team_keys": ["frc16", "frc17", "frc18"]}}},
+		{"comp_level": "f", "set_number": 1, "match_number": 1, "time": null,
+			"alliances": {"red": {"score": -1, "team_keys": ["frc1", "frc2", "frc3"]},
+				"blue": {"score": -1, "team_keys": ["frc4", "frc5", "frc6"]}}}
+	]`)
+	return api, NewTBA(server.URL+"/api/v3/", tbaTestKey)
+}
+
+func TestTBAEvent(t *testing.T) {
+	_, tba := newFakeTBA(t)
+	comp, err := tba.Event("2018casj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comp.Key != "2018casj" || comp.Name != "Silicon Valley Regional" || comp.Location != "San Jose, CA, USA" ||
+		comp.Start.String() != "2018-03-28 00:00:00" || comp.End.String() != "2018-03-31 00:00:00" {
+		t.Errorf("Event = %+v", comp)
+	}
+
+	teams, err := tba.EventTeams("2018casj")
+	if err != nil || !reflect.DeepEqual(teams, []int{254, 971, 4476}) {
+		t.Errorf("EventTeams = %v, %v", teams, err)
+	}
+
+	if _, err = tba.Event("2018nope"); err != ErrCompetitionNotFound {
+		t.Errorf("Event of an unknown competition = %v, want ErrCompetitionNotFound", err)
+	}
+	if _, err = NewTBA(tba.baseURL, "wrong key").Event("2018casj"); err != ErrSourceAccessDenied {
+		t.Errorf("Event with the wrong key = %v, want ErrSourceAccessDenied", err)
+	}
+}
+
+func TestTBAEventMatches(t *testing.T) {
+	api, tba := newFakeTBA(t)
+	matches, err := tba.EventMatches("2018casj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Match{
+		{Level: Qualification, Number: 2, Red: []int{254, 971, 4476}, Blue: []int{1, 2, 3}},
+		{Level: Qualification, Number: 1, Red: []int{4476, 254, 971}, Blue: []int{1, 2, 3},
+			Scored: true, RedScore: 310, BlueScore: 120},
+		// playoffs by round, set and match, whether they're scheduled or not
+		{Level: Playoff, Number: 1, Red: []int{13, 14, 15}, Blue: []int{16, 17, 18}, Scored: true, RedScore: 80, BlueScore: 70},
+		{Level: Playoff, Number: 2, Red: []int{13, 14, 15}, Blue: []int{16, 17, 18}, Scored: true, RedScore: 90, BlueScore: 95},
+		{Level: Playoff, Number: 3, Red: []int{7, 8, 9}, Blue: []int{10, 11, 12}, Scored: true, RedScore: 200, BlueScore: 150},
+		{Level: Playoff, Number: 4, Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}},
+		{Level: Playoff, Number: 5, Red: []int{1, 2, 3}, Blue: []int{4, 5, 6}},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("EventMatches =\n%+v\nwant\n%+v", matches, want)
+	}
+
+	// asking again is answered from the cache
+	again, err := tba.EventMatches("2018casj")
+	if err != nil || !reflect.DeepEqual(again, want) {
+		t.Errorf("EventMatches from the cache = %+v, %v", again, err)
+	}
+	if requests, notModified := api.counts(); requests != 2 || notModified != 1 {
+		t.Errorf("the API answered %d requests, %d of them not modified; want 2 and 1", requests, notModified)
+	}
+
+	// a change is noticed
+	api.set("/api/v3/event/2018casj/matches/simple", `[]`)
+	if matches, err = tba.EventMatches("2018casj"); err != nil || len(matches) != 0 {
+		t.Errorf("EventMatches after a change = %+v, %v", matches, err)
+	}
+}
+
+func TestTBAImportKeepsPlayoffNumbers(t *testing.T) {
+	api, tba := newFakeTBA(t)
+	db := newTestDB(t)
+	if err := db.ImportCompetition(tba, "2018casj"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the semifinal and final are scheduled, and the final is played
+	api.set("/api/v3/event/2018casj/matches/simple", `[
+		{"comp_level": "f", "set_number": 1, "match_number": 1, "time": 1522600000,
+			"alliances": {"red": {"score": 100, "team_keys": ["frc1", "frc2", "frc3"]},
+				"blue": {"score": 99, "team_keys": ["frc4", "frc5", "frc6"]}}},
+		{"comp_level": "sf", "set_number": 1, "match_number": 1, "time": 1522590000,
+			"alliances": {"red": {"score": -1, "team_keys": ["frc1", "frc2", "frc3"]},
+				"blue": {"score": -1, "team_keys": ["frc4", "frc5", "frc6"]}}},
+		{"comp_level": "qf", "set_number": 1, "match_number": 1, "time": 1522530000,
+			"alliances": {"red": {"score": 80, "team_keys": ["frc13", "frc14", "frc15"]},
+				"blue": {"score": 70, "team_keys": ["frc16", "frc17", "frc18"]}}},
+		{"comp_level": "qf", "set_number": 1, "match_number": 2, "time": 1522560000,
+			"alliances": {"red": {"score": 90, "team_keys": ["frc13", "frc14", "frc15"]},
+				"blue": {"score": 95, "team_keys": ["frc16", "frc17", "frc18"]}}},
+		{"comp_level": "qf", "set_number": 2, "match_number": 1, "time": 1522540000,
+			"alliances": {"red": {"score": 200, "team_keys": ["frc7", "frc8", "frc9"]},
+				"blue": {"score": 150, "team_keys": ["frc10", "frc11", "frc12"]}}}
+	]`)
+	if err := db.ImportCompetition(tba, "2018casj"); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := db.GetMatches("2018casj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	playoffs := map[int]Match{}
+	for _, match := range matches {
+		if match.Level == Playoff {
+			playoffs[match.Number] = match
+		}
+	}
+	if len(playoffs) != 5 {
+		t.Fatalf("stored %d playoff matches, want 5", len(playoffs))
+	}
+	if final := playoffs[5]; !final.Scored || final.RedScore != 100 || final.BlueScore != 99 {
+		t.Errorf("the final is stored as %+v", final)
+	}
+	if semifinal := playoffs[4]; semifinal.Scored || !reflect.DeepEqual(semifinal.Red, []int{1, 2, 3}) {
+		t.Errorf("the semifinal is stored as %+v", semifinal)
+	}
+}
+
+func TestImportCompetitionUppercaseKey(t *testing.T) {
+	_, tba := newFakeTBA(t)
+	db := newTestDB(t)
+	if err := db.ImportCompetition(tba, "2018CASJ"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ImportCompetition(tba, "2018casj"); err != nil {
+		t.Fatal(err)
+	}
+
+	comps, err := db.GetCompetitions()
+	if err != nil || len(comps) != 1 || comps[0].Key != "2018casj" || comps[0].Source != "tba" {
+		t.Fatalf("GetCompetitions = %+v, %v", comps, err)
+	}
+	comp, err := db.GetCompetition("2018casj")
+	if err != nil || len(comp.Teams) != 3 {
+		t.Errorf("GetCompetition = %+v, %v", comp, err)
+	}
+	matches, err := db.GetMatches("2018casj")
+	if err != nil || len(matches) != 7 {
+		t.Errorf("GetMatches = %d matches, %v", len(matches), err)
+	}
+}
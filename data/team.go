@@ -0,0 +1,49 @@
+package data
+
+import (
+	"fmt"
+	"os"
+)
+
+// GetTeams retrieves the number of every team that is either attending a
+// competition or has been scouted during the year, in ascending order.
+func (db DB) GetTeams() ([]int, error) {
+	rows, err := db.db.Query(`SELECT team FROM competition_teams UNION SELECT team FROM submissions ORDER BY team`)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "data.GetTeams: "+err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []int{}
+	for rows.Next() {
+		var team int
+		if err = rows.Scan(&team); err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, rows.Err()
+}
+
+// GetTeamCompetitions retrieves the event keys of every competition a team is
+// attending, ordered by the date they start.
+func (db DB) GetTeamCompetitions(team int) ([]string, error) {
+	rows, err := db.db.Query(`SELECT c.event_key
+ FROM competitions c JOIN competition_teams t ON t.competition=c.event_key
+ WHERE t.team=? ORDER BY c.start_date`, team)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
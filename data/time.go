@@ -1,6 +1,7 @@
 package data
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"time"
 )
@@ -22,6 +23,11 @@ func (ts Timestamp) String() string {
 		ts.time.Hour(), ts.time.Minute(), ts.time.Second())
 }
 
+// Date returns only the date portion of the Timestamp
+func (ts Timestamp) Date() string {
+	return fmt.Sprintf("%04d-%02d-%02d", ts.time.Year(), ts.time.Month(), ts.time.Day())
+}
+
 // Local converts the Timestamp into the standard Go representation of time in
 // the local timezone.
 func (ts Timestamp) Local() time.Time {
@@ -37,3 +43,38 @@ func (ts Timestamp) Add(dur time.Duration) Timestamp {
 func (ts Timestamp) Year() int {
 	return ts.time.Year()
 }
+
+// timestampLayout is the format used to store Timestamps in the database
+const timestampLayout = "2006-01-02 15:04:05"
+
+// Value stores the Timestamp in the database using its string representation.
+func (ts Timestamp) Value() (driver.Value, error) {
+	return ts.String(), nil
+}
+
+// Scan reads a Timestamp back out of a database column.  Both native time
+// values and the textual representation produced by String are accepted.
+func (ts *Timestamp) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		ts.time = v.UTC()
+	case []byte:
+		return ts.parse(string(v))
+	case string:
+		return ts.parse(v)
+	case nil:
+		ts.time = time.Time{}
+	default:
+		return fmt.Errorf("data.Timestamp: cannot scan %T", src)
+	}
+	return nil
+}
+
+func (ts *Timestamp) parse(s string) error {
+	t, err := time.Parse(timestampLayout, s)
+	if err != nil {
+		return err
+	}
+	ts.time = t
+	return nil
+}
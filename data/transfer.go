@@ -0,0 +1,262 @@
+package data
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// transferVersion is written at the start of every encoded submission.  It
+// must change whenever the layout below does, so that old payloads still in
+// someone's camera roll are rejected instead of misread.
+const transferVersion = 1
+
+// transfer flag bits describing the match of a submission
+const (
+	transferBlue    = 1 << iota // the robot was on the blue alliance
+	transferPlayoff             // the match was a playoff match
+)
+
+// base45Alphabet holds the characters of RFC 9285 base45, which are exactly
+// the characters a QR code can store most compactly
+const base45Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// EncodeSubmission packs a submission into a short base45 string suitable for
+// a QR code, so it can be carried to a device that can reach the server.  The
+// values are written in the order of the season's fields instead of by name,
+// which keeps the code small but means the same season is needed to decode it.
+//
+// The layout is a version byte, the year, the competition, a flag byte, the
+// match, team and scout, the client id, then one value per field: counters as
+// signed varints, booleans as a byte, enums as the index of their option and
+// text as a length-prefixed string.
+func EncodeSubmission(season *Season, sub Submission) (string, error) {
+	if err := sub.Validate(season); err != nil {
+		return "", err
+	}
+
+	buf := []byte{transferVersion}
+	buf = binary.AppendUvarint(buf, uint64(season.Year))
+	buf = appendString(buf, sub.Competition)
+	flags := byte(0)
+	if sub.Alliance == Blue {
+		flags |= transferBlue
+	}
+	if sub.Level == Playoff {
+		flags |= transferPlayoff
+	}
+	buf = append(buf, flags)
+	buf = binary.AppendUvarint(buf, uint64(sub.Match))
+	buf = binary.AppendUvarint(buf, uint64(sub.Team))
+	buf = binary.AppendUvarint(buf, uint64(sub.ScoutId))
+	buf = appendString(buf, sub.ClientId)
+
+	for _, field := range season.Fields {
+		value := sub.Values[field.Name]
+		switch field.Kind {
+		case FieldCounter:
+			n, _ := strconv.Atoi(value)
+			buf = binary.AppendVarint(buf, int64(n))
+		case FieldBoolean:
+			if value == "1" {
+				buf = append(buf, 1)
+			} else {
+				buf = append(buf, 0)
+			}
+		case FieldEnum:
+			for i, option := range field.Options {
+				if option == value {
+					buf = binary.AppendUvarint(buf, uint64(i))
+					break
+				}
+			}
+		case FieldText:
+			buf = appendString(buf, value)
+		}
+	}
+	return encodeBase45(buf), nil
+}
+
+// DecodeSubmission unpacks a submission packed by EncodeSubmission.  Returns
+// ErrTransferVersion if it was packed by an incompatible version of the
+// scouting system, ErrNoSeason if it is for a year other than the given
+// one, and ErrInvalidTransfer if it is damaged in any other way.
+func DecodeSubmission(payload string, year int) (*Submission, error) {
+	buf, err := decodeBase45(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) == 0 {
+		return nil, ErrInvalidTransfer
+	}
+	if buf[0] != transferVersion {
+		return nil, ErrTransferVersion
+	}
+	reader := transferReader{buf: buf[1:]}
+
+	if int(reader.uvarint()) != year || reader.err != nil {
+		return nil, ErrNoSeason
+	}
+	season, err := GetSeason(year)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &Submission{Values: map[string]string{}}
+	sub.Competition = reader.string()
+	flags := reader.byte()
+	if flags&transferBlue != 0 {
+		sub.Alliance = Blue
+	}
+	if flags&transferPlayoff != 0 {
+		sub.Level = Playoff
+	}
+	sub.Match = int(reader.uvarint())
+	sub.Team = int(reader.uvarint())
+	sub.ScoutId = int64(reader.uvarint())
+	sub.ClientId = reader.string()
+
+	for _, field := range season.Fields {
+		switch field.Kind {
+		case FieldCounter:
+			sub.Values[field.Name] = strconv.FormatInt(reader.varint(), 10)
+		case FieldBoolean:
+			sub.Values[field.Name] = strconv.Itoa(int(reader.byte()))
+		case FieldEnum:
+			i := reader.uvarint()
+			if i >= uint64(len(field.Options)) {
+				return nil, ErrInvalidTransfer
+			}
+			sub.Values[field.Name] = field.Options[i]
+		case FieldText:
+			sub.Values[field.Name] = reader.string()
+		}
+	}
+	if reader.err != nil || len(reader.buf) != 0 {
+		return nil, ErrInvalidTransfer
+	}
+	if sub.Validate(season) != nil {
+		return nil, ErrInvalidTransfer
+	}
+	return sub, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// transferReader reads the parts of an encoded submission in turn.  Once a
+// read fails every later one returns a zero value and err stays set.
+type transferReader struct {
+	buf []byte
+	err error
+}
+
+func (reader *transferReader) uvarint() uint64 {
+	if reader.err != nil {
+		return 0
+	}
+	n, size := binary.Uvarint(reader.buf)
+	if size <= 0 {
+		reader.err = ErrInvalidTransfer
+		return 0
+	}
+	reader.buf = reader.buf[size:]
+	return n
+}
+
+func (reader *transferReader) varint() int64 {
+	if reader.err != nil {
+		return 0
+	}
+	n, size := binary.Varint(reader.buf)
+	if size <= 0 {
+		reader.err = ErrInvalidTransfer
+		return 0
+	}
+	reader.buf = reader.buf[size:]
+	return n
+}
+
+func (reader *transferReader) byte() byte {
+	if reader.err != nil {
+		return 0
+	}
+	if len(reader.buf) == 0 {
+		reader.err = ErrInvalidTransfer
+		return 0
+	}
+	b := reader.buf[0]
+	reader.buf = reader.buf[1:]
+	return b
+}
+
+func (reader *transferReader) string() string {
+	n := reader.uvarint()
+	if reader.err != nil {
+		return ""
+	}
+	if n > uint64(len(reader.buf)) {
+		reader.err = ErrInvalidTransfer
+		return ""
+	}
+	s := string(reader.buf[:n])
+	reader.buf = reader.buf[n:]
+	return s
+}
+
+// encodeBase45 encodes every two bytes as three base45 characters, and a
+// trailing odd byte as two, least significant first
+func encodeBase45(buf []byte) string {
+	var builder strings.Builder
+	for i := 0; i < len(buf); i += 2 {
+		if i+1 < len(buf) {
+			n := int(buf[i])<<8 | int(buf[i+1])
+			builder.WriteByte(base45Alphabet[n%45])
+			builder.WriteByte(base45Alphabet[n/45%45])
+			builder.WriteByte(base45Alphabet[n/(45*45)])
+		} else {
+			n := int(buf[i])
+			builder.WriteByte(base45Alphabet[n%45])
+			builder.WriteByte(base45Alphabet[n/45])
+		}
+	}
+	return builder.String()
+}
+
+// decodeBase45 reverses encodeBase45.  Returns ErrInvalidTransfer if the
+// string contains anything that encodeBase45 couldn't have written.
+func decodeBase45(s string) ([]byte, error) {
+	if len(s)%3 == 1 {
+		return nil, ErrInvalidTransfer
+	}
+	buf := make([]byte, 0, len(s)/3*2+1)
+	for i := 0; i < len(s); i += 3 {
+		n, scale := 0, 1
+		end := i + 3
+		if end > len(s) {
+			end = len(s)
+		}
+		for j := i; j < end; j++ {
+			digit := strings.IndexByte(base45Alphabet, s[j])
+			if digit < 0 {
+				return nil, ErrInvalidTransfer
+			}
+			n += digit * scale
+			scale *= 45
+		}
+		if end-i == 3 {
+			if n > 0xffff {
+				return nil, ErrInvalidTransfer
+			}
+			buf = append(buf, byte(n>>8), byte(n))
+		} else {
+			if n > 0xff {
+				return nil, ErrInvalidTransfer
+			}
+			buf = append(buf, byte(n))
+		}
+	}
+	return buf, nil
+}
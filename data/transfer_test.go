@@ -0,0 +1,120 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBase45(t *testing.T) {
+	// the examples of RFC 9285
+	tests := []struct {
+		decoded, encoded string
+	}{
+		{"", ""},
+		{"AB", "BB8"},
+		{"Hello!!", "%69 VD92EX0"},
+		{"base-45", "UJCLQE7W581"},
+		{"ietf!", "QED8WEX0"},
+	}
+	for _, test := range tests {
+		if encoded := encodeBase45([]byte(test.decoded)); encoded != test.encoded {
+			t.Errorf("encodeBase45(%q) = %q, want %q", test.decoded, encoded, test.encoded)
+		}
+		decoded, err := decodeBase45(test.encoded)
+		if err != nil || string(decoded) != test.decoded {
+			t.Errorf("decodeBase45(%q) = %q, %v, want %q", test.encoded, decoded, err, test.decoded)
+		}
+	}
+}
+
+func TestDecodeBase45Invalid(t *testing.T) {
+	for _, encoded := range []string{
+		"A",    // a lone character can't hold a byte
+		"GGW",  // 65536 doesn't fit in two bytes
+		"ZZ",   // 1610 doesn't fit in one byte
+		"bb8",  // lowercase isn't base45
+		"B\nB", // neither is whitespace other than spaces
+	} {
+		if _, err := decodeBase45(encoded); err != ErrInvalidTransfer {
+			t.Errorf("decodeBase45(%q) = %v, want ErrInvalidTransfer", encoded, err)
+		}
+	}
+}
+
+func testSubmission() Submission {
+	return Submission{
+		Competition: "2018casj",
+		Level:       Playoff,
+		Match:       12,
+		Team:        4476,
+		Alliance:    Blue,
+		ScoutId:     7,
+		ClientId:    "c0ffee-42",
+		Values: map[string]string{
+			"auto-run":    "1",
+			"auto-switch": "2",
+			"auto-scale":  "0",
+			"switch":      "5",
+			"scale":       "11",
+			"vault":       "3",
+			"endgame":     "climbed",
+			"fouls":       "1",
+			"comments":    "Fast drivetrain; tipped once. Ünïcödé too",
+		},
+	}
+}
+
+func TestSubmissionTransferRoundTrip(t *testing.T) {
+	season, err := GetSeason(testYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := testSubmission()
+	payload, err := EncodeSubmission(season, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeSubmission(payload, testYear)
+	if err != nil {
+		t.Fatalf("DecodeSubmission(%q) failed: %v", payload, err)
+	}
+	if !reflect.DeepEqual(*decoded, sub) {
+		t.Errorf("round trip gave %+v, want %+v", *decoded, sub)
+	}
+
+	sub.Level, sub.Alliance, sub.ClientId = Qualification, Red, ""
+	sub.Values["comments"] = ""
+	if payload, err = EncodeSubmission(season, sub); err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err = DecodeSubmission(payload, testYear); err != nil || !reflect.DeepEqual(*decoded, sub) {
+		t.Errorf("round trip gave %+v, %v, want %+v", decoded, err, sub)
+	}
+}
+
+func TestDecodeSubmissionDamaged(t *testing.T) {
+	season, err := GetSeason(testYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := EncodeSubmission(season, testSubmission())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = DecodeSubmission(payload, testYear-1); err != ErrNoSeason {
+		t.Errorf("decoding for another year = %v, want ErrNoSeason", err)
+	}
+	if _, err = DecodeSubmission(payload[:len(payload)-3], testYear); err != ErrInvalidTransfer {
+		t.Errorf("decoding a truncated payload = %v, want ErrInvalidTransfer", err)
+	}
+	if _, err = DecodeSubmission(payload+"000", testYear); err != ErrInvalidTransfer {
+		t.Errorf("decoding a payload with bytes left over = %v, want ErrInvalidTransfer", err)
+	}
+	if _, err = DecodeSubmission("", testYear); err != ErrInvalidTransfer {
+		t.Errorf("decoding nothing = %v, want ErrInvalidTransfer", err)
+	}
+	if _, err = DecodeSubmission(encodeBase45([]byte{transferVersion + 1}), testYear); err != ErrTransferVersion {
+		t.Errorf("decoding a later version = %v, want ErrTransferVersion", err)
+	}
+}
@@ -12,10 +12,11 @@ rm -f scout.tar.gz # clean up old deploys if there was a problem
 
 # build the deployed archive
 go install scout
-tar -czf scout.tar.gz img js css "../../bin/scout"
+tar -czf scout.tar.gz img js css seasons "../../bin/scout"
 
 # put the archive on the server
 printf 'put scout.tar.gz' | sftp  -i "$2" "$1"
-printf 'rm -rf scout; mkdir scout; cd scout; tar -xzf ../scout.tar.gz; rm ../scout.tar.gz' | ssh  -i "$2" "$1"
+# uploaded photos and SQLite databases live in the deployed directory, so keep them across deploys
+printf 'mkdir -p scout/photos; mv scout/photos photos; mv scout/*.db . 2>/dev/null; rm -rf scout; mkdir scout; mv photos *.db scout 2>/dev/null; cd scout; tar -xzf ../scout.tar.gz; rm ../scout.tar.gz' | ssh  -i "$2" "$1"
 
 rm scout.tar.gz # clean up
\ No newline at end of file
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"scout/data"
+	"strconv"
+	"strings"
+)
+
+// exportKinds lists the kinds of data that can be exported, in the order
+// they appear in a JSON export of everything
+var exportKinds = []string{"submissions", "pit", "matches"}
+
+// exportHandler writes every submission, pit record and match result of the
+// year, optionally only for one competition, for use in other tools.  CSV
+// exports hold one kind of data chosen by the data parameter, which defaults
+// to submissions.  JSON exports hold every kind unless one is chosen.  Only
+// logged in users may export.
+func exportHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+	if db.GetUser(request) == nil {
+		http.Redirect(writer, request, "/login", http.StatusFound)
+		return nil
+	}
+
+	season, err := data.GetSeason(year)
+	if err != nil {
+		return err
+	}
+
+	competition := request.FormValue("competition")
+	kind := request.FormValue("data")
+	if kind != "" && !contains(exportKinds, kind) {
+		return data.ErrMalformedRequest
+	}
+
+	name := fmt.Sprintf("scout-%d", year)
+	if competition != "" {
+		if _, err = db.GetCompetition(competition); err != nil {
+			return err
+		}
+		name = "scout-" + competition
+	}
+
+	switch request.FormValue("format") {
+	case "csv", "":
+		if kind == "" {
+			kind = "submissions"
+		}
+		writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		writer.Header().Set("Content-Disposition", attachment(name+"-"+kind+".csv"))
+		return exportCSV(db, season, competition, kind, writer)
+	case "json":
+		kinds := exportKinds
+		if kind != "" {
+			kinds = []string{kind}
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set("Content-Disposition", attachment(name+".json"))
+		return exportJSON(db, season, competition, kinds, writer)
+	}
+	return data.ErrMalformedRequest
+}
+
+// attachment builds a Content-Disposition header that has the browser save
+// the response as a file with the given name
+func attachment(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
+
+// csvFormulaPrefixes are what a cell starts with for spreadsheets to treat it
+// as a formula
+const csvFormulaPrefixes = "=+-@"
+
+// writeCSVRow writes a row with every cell a spreadsheet would take as a
+// formula prefixed with a quote, so that a comment such as =HYPERLINK(...)
+// typed into a submission is shown instead of run by whoever opens the export
+func writeCSVRow(out *csv.Writer, row []string) {
+	for i, cell := range row {
+		if cell != "" && strings.ContainsRune(csvFormulaPrefixes, rune(cell[0])) {
+			row[i] = "'" + cell
+		}
+	}
+	out.Write(row)
+}
+
+// exportCSV writes one kind of data as CSV with a header row.  Field columns
+// are named after the fields of the season, and cells are escaped against
+// formulas.
+func exportCSV(db data.DB, season *data.Season, competition, kind string, w io.Writer) error {
+	out := csv.NewWriter(w)
+	switch kind {
+	case "submissions":
+		subs, err := db.GetSubmissions(data.SubmissionFilter{Competition: competition})
+		if err != nil {
+			return err
+		}
+		header := []string{"id", "competition", "level", "match", "team", "alliance", "scout", "time"}
+		writeCSVRow(out, append(header, fieldNames(season.Fields)...))
+		for _, sub := range subs {
+			row := []string{strconv.FormatInt(sub.Id, 10), sub.Competition, sub.Level.String(), strconv.Itoa(sub.Match),
+				strconv.Itoa(sub.Team), sub.Alliance.String(), sub.ScoutName, sub.Time.String()}
+			writeCSVRow(out, append(row, fieldValues(season.Fields, sub.Values)...))
+		}
+	case "pit":
+		records, err := db.GetPitRecords(competition)
+		if err != nil {
+			return err
+		}
+		header := []string{"id", "competition", "team", "scout", "time"}
+		writeCSVRow(out, append(header, fieldNames(season.PitFields)...))
+		for _, record := range records {
+			row := []string{strconv.FormatInt(record.Id, 10), record.Competition, strconv.Itoa(record.Team),
+				record.ScoutName, record.Time.String()}
+			writeCSVRow(out, append(row, fieldValues(season.PitFields, record.Values)...))
+		}
+	case "matches":
+		matches, err := db.GetMatches(competition)
+		if err != nil {
+			return err
+		}
+		out.Write([]string{"competition", "level", "match", "red1", "red2", "red3", "blue1", "blue2", "blue3",
+			"red_score", "blue_score"})
+		for _, match := range matches {
+			row := []string{match.Competition, match.Level.String(), strconv.Itoa(match.Number)}
+			for _, team := range match.Teams() {
+				row = append(row, strconv.Itoa(team))
+			}
+			if match.Scored {
+				row = append(row, strconv.Itoa(match.RedScore), strconv.Itoa(match.BlueScore))
+			} else {
+				row = append(row, "", "")
+			}
+			writeCSVRow(out, row)
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+type exportSubmission struct {
+	Id          int64                  `json:"id"`
+	Competition string                 `json:"competition"`
+	Level       string                 `json:"level"`
+	Match       int                    `json:"match"`
+	Team        int                    `json:"team"`
+	Alliance    string                 `json:"alliance"`
+	Scout       string                 `json:"scout"`
+	Time        string                 `json:"time"`
+	Values      map[string]interface{} `json:"values"`
+}
+
+type exportPitRecord struct {
+	Id          int64                  `json:"id"`
+	Competition string                 `json:"competition"`
+	Team        int                    `json:"team"`
+	Scout       string                 `json:"scout"`
+	Time        string                 `json:"time"`
+	Values      map[string]interface{} `json:"values"`
+}
+
+type exportMatch struct {
+	Competition string `json:"competition"`
+	Level       string `json:"level"`
+	Match       int    `json:"match"`
+	Red         []int  `json:"red"`
+	Blue        []int  `json:"blue"`
+	RedScore    *int   `json:"redScore"`
+	BlueScore   *int   `json:"blueScore"`
+}
+
+// exportJSON writes an object with an array for each kind of data.  Each
+// element is written as soon as it's ready instead of building the whole
+// document first.  Values are typed by their field: counters are numbers,
+// booleans are booleans and everything else is a string.
+func exportJSON(db data.DB, season *data.Season, competition string, kinds []string, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	writeElements := func(kind string, n int, element func(i int) interface{}) error {
+		if _, err := fmt.Fprintf(w, "%q:[", kind); err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			if err := encoder.Encode(element(i)); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "]")
+		return err
+	}
+
+	io.WriteString(w, "{")
+	for i, kind := range kinds {
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		var err error
+		switch kind {
+		case "submissions":
+			var subs []data.Submission
+			if subs, err = db.GetSubmissions(data.SubmissionFilter{Competition: competition}); err != nil {
+				return err
+			}
+			err = writeElements(kind, len(subs), func(i int) interface{} {
+				sub := subs[i]
+				return exportSubmission{sub.Id, sub.Competition, sub.Level.String(), sub.Match, sub.Team,
+					sub.Alliance.String(), sub.ScoutName, sub.Time.String(), typedValues(season.Fields, sub.Values)}
+			})
+		case "pit":
+			var records []data.PitRecord
+			if records, err = db.GetPitRecords(competition); err != nil {
+				return err
+			}
+			err = writeElements(kind, len(records), func(i int) interface{} {
+				record := records[i]
+				return exportPitRecord{record.Id, record.Competition, record.Team, record.ScoutName,
+					record.Time.String(), typedValues(season.PitFields, record.Values)}
+			})
+		case "matches":
+			var matches []data.Match
+			if matches, err = db.GetMatches(competition); err != nil {
+				return err
+			}
+			err = writeElements(kind, len(matches), func(i int) interface{} {
+				match := matches[i]
+				exported := exportMatch{Competition: match.Competition, Level: match.Level.String(),
+					Match: match.Number, Red: match.Red, Blue: match.Blue}
+				if match.Scored {
+					exported.RedScore, exported.BlueScore = &match.RedScore, &match.BlueScore
+				}
+				return exported
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "}\n")
+	return err
+}
+
+func fieldNames(fields []data.Field) []string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+func fieldValues(fields []data.Field, values map[string]string) []string {
+	row := make([]string, len(fields))
+	for i, field := range fields {
+		row[i] = values[field.Name]
+	}
+	return row
+}
+
+func typedValues(fields []data.Field, values map[string]string) map[string]interface{} {
+	typed := map[string]interface{}{}
+	for _, field := range fields {
+		value := values[field.Name]
+		switch field.Kind {
+		case data.FieldCounter:
+			if n, err := strconv.Atoi(value); err == nil {
+				typed[field.Name] = n
+				continue
+			}
+		case data.FieldBoolean:
+			typed[field.Name] = value == "1"
+			continue
+		}
+		typed[field.Name] = value
+	}
+	return typed
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
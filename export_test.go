@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"scout/data"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newExportDB makes a database with a submission to export, returning the
+// cookie of a scout logged in to it
+func newExportDB(t *testing.T) (data.DB, *http.Cookie) {
+	if err := data.LoadSeasons("seasons"); err != nil {
+		t.Fatal(err)
+	}
+	memory := data.NewMemory()
+	data.SetBackend(memory)
+	db, err := data.ConnectToDatabase(2018)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err = db.InsertCompetition(data.Competition{Key: "2018casj", Name: "Silicon Valley Regional"}); err != nil {
+		t.Fatal(err)
+	}
+	sub := data.Submission{Competition: "2018casj", Match: 1, Team: 4476, Values: map[string]string{
+		"auto-run": "1", "auto-switch": "0", "auto-scale": "1", "switch": "2", "scale": "3", "vault": "4",
+		"endgame": "climbed", "fouls": "0", "comments": `=HYPERLINK("http://example.com", "click")`,
+	}}
+	if err = db.InsertSubmission(&sub); err != nil {
+		t.Fatal(err)
+	}
+
+	// users are only made by admins, so the first is added straight to the
+	// database
+	raw, err := memory.Open(2018)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passhash, err := bcrypt.GenerateFromPassword([]byte("a password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = raw.Exec(`INSERT INTO users (username, realname, passhash) VALUES (?, ?, ?)`,
+		"scout", "Scout", passhash); err != nil {
+		t.Fatal(err)
+	}
+	cookie, err := db.Login("scout", "a password", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, cookie
+}
+
+// exportRequest requests an export with the cookie, if there is one
+func exportRequest(cookie *http.Cookie, target string) *http.Request {
+	request := httptest.NewRequest("GET", target, nil)
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+	return request
+}
+
+func TestExportLoggedOut(t *testing.T) {
+	db, _ := newExportDB(t)
+	writer := httptest.NewRecorder()
+	if err := exportHandler(2018, db, writer, exportRequest(nil, "/export?format=json")); err != nil {
+		t.Fatal(err)
+	}
+	if writer.Code != http.StatusFound || writer.Header().Get("Location") != "/login" ||
+		strings.Contains(writer.Body.String(), "HYPERLINK") {
+		t.Errorf("a logged out export got status %d and %q", writer.Code, writer.Body.String())
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	db, cookie := newExportDB(t)
+	writer := httptest.NewRecorder()
+	if err := exportHandler(2018, db, writer, exportRequest(cookie, "/export?competition=2018casj")); err != nil {
+		t.Fatal(err)
+	}
+
+	disposition, params, err := mime.ParseMediaType(writer.Header().Get("Content-Disposition"))
+	if err != nil || disposition != "attachment" || params["filename"] != "scout-2018casj-submissions.csv" {
+		t.Errorf("Content-Disposition is %q", writer.Header().Get("Content-Disposition"))
+	}
+	rows, err := csv.NewReader(writer.Body).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("the export has %d rows, err %v", len(rows), err)
+	}
+	comments := rows[1][len(rows[1])-1]
+	if comments != `'=HYPERLINK("http://example.com", "click")` {
+		t.Errorf("the comments were exported as %q", comments)
+	}
+
+	// the export imports back as it was
+	var buffer strings.Builder
+	out := csv.NewWriter(&buffer)
+	out.WriteAll(rows)
+	imp, err := data.ReadCSVImport(data.ImportSubmissions, strings.NewReader(buffer.String()))
+	if err != nil || imp.Rows[0][len(imp.Rows[0])-1] != comments[1:] {
+		t.Errorf("the comments were imported as %q, err %v", imp.Rows[0][len(imp.Rows[0])-1], err)
+	}
+}
+
+func TestExportUnknownCompetition(t *testing.T) {
+	db, cookie := newExportDB(t)
+	request := exportRequest(cookie, `/export?competition=%22%0D%0ASet-Cookie:%20x&format=json`)
+	if err := exportHandler(2018, db, httptest.NewRecorder(), request); err != data.ErrCompetitionNotFound {
+		t.Errorf("exporting an unknown competition = %v, want ErrCompetitionNotFound", err)
+	}
+}
+
+func TestWriteCSVRow(t *testing.T) {
+	var buffer strings.Builder
+	out := csv.NewWriter(&buffer)
+	writeCSVRow(out, []string{"=1+1", "+1", "-1", "@SUM(A1)", "1-1", "", "plain"})
+	out.Flush()
+	if want := "'=1+1,'+1,'-1,'@SUM(A1),1-1,,plain\n"; buffer.String() != want {
+		t.Errorf("writeCSVRow wrote %q, want %q", buffer.String(), want)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"html"
 	"io"
 	"net"
 	"net/http"
@@ -12,29 +13,57 @@ import (
 	"time"
 )
 
-type safeHandler func(year int, writer http.ResponseWriter, request *http.Request) error
+// safeHandler is a handler given the year the request is for, which comes from
+// the subdomain when there is one, along with the database of that year.  The
+// database is shared with every other request, so handlers must not close it.
+type safeHandler func(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error
 
 func (handler safeHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	year := time.Now().Year()
 
-	if net.ParseIP(request.Host) != nil {
-		handleError(handler(year, writer, request), writer)
-	}
-
-	hostParts := strings.Split(request.Host, ".")
-	if len(hostParts) == 2 {
-		tmp, err := strconv.ParseInt(hostParts[0], 10, 32)
-		year = int(tmp)
-		if err != nil || year < 2017 || year > time.Now().Year() {
+	if net.ParseIP(request.Host) == nil {
+		hostParts := strings.Split(request.Host, ".")
+		if len(hostParts) == 2 {
+			tmp, err := strconv.ParseInt(hostParts[0], 10, 32)
+			year = int(tmp)
+			if err != nil || year < 2017 || year > time.Now().Year() {
+				handleError(data.ErrNotFound, writer)
+				return
+			}
+		} else if len(hostParts) > 2 {
 			handleError(data.ErrNotFound, writer)
 			return
 		}
-	} else if len(hostParts) > 2 {
-		handleError(data.ErrNotFound, writer)
+	}
+
+	db, err := data.GetDatabase(year)
+	if err != nil {
+		handleError(err, writer)
+		return
+	}
+	err = handler(year, db, writer, request)
+	if err == data.ErrNoSeason {
+		handleNoSeason(year, writer)
 		return
 	}
+	handleError(err, writer)
+}
 
-	handleError(handler(year, writer, request), writer)
+// handleNoSeason explains that a page can't be shown because nobody has
+// described the game of the year yet, naming the file that would describe it
+func handleNoSeason(year int, writer http.ResponseWriter) {
+	fmt.Fprintln(os.Stderr, "handleError: no season file "+data.SeasonFile(year))
+
+	writer.WriteHeader(http.StatusNotFound)
+	writer.Write([]byte(fmt.Sprintf(`<!DOCTYPE html>
+<html>
+	<head>
+		<title>No %d Season</title>
+	</head>
+	<body>
+		The scouting system doesn't know the %d game yet.  Describe it in %s and restart the server.
+	</body>
+</html>`, year, year, html.EscapeString(data.SeasonFile(year)))))
 }
 
 func handleError(err error, writer http.ResponseWriter) {
@@ -45,8 +74,13 @@ func handleError(err error, writer http.ResponseWriter) {
 
 	code := http.StatusInternalServerError // the default error value
 	switch err {
-	case data.ErrNotFound:
+	case data.ErrNotFound, data.ErrNoSeason, data.ErrCompetitionNotFound, data.ErrSubmissionNotFound,
+		data.ErrPickListNotFound, data.ErrPhotoNotFound:
 		code = http.StatusNotFound
+	case data.ErrPhotoTooLarge:
+		code = http.StatusRequestEntityTooLarge
+	case data.ErrUnsupportedPhoto:
+		code = http.StatusUnsupportedMediaType
 	case data.ErrHTTPMethodUnsupported:
 		code = http.StatusMethodNotAllowed
 	case data.ErrAccessDenied:
@@ -65,7 +99,7 @@ func handleError(err error, writer http.ResponseWriter) {
 </html>`, code, code)))
 }
 
-func indexHandler(year int, writer http.ResponseWriter, request *http.Request) error {
+func indexHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
 	if request.URL.Path != "/" {
 		if page, ok := staticPages[request.URL.Path]; ok {
 			return page.ServeHTTP(writer, request)
@@ -73,22 +107,22 @@ func indexHandler(year int, writer http.ResponseWriter, request *http.Request) e
 		return data.ErrNotFound
 	}
 
+	next, err := genNextAssignment(db, db.GetUser(request))
+	if err != nil {
+		return err
+	}
+
 	return writeAll(writer,
 		genPageStart("Scouting System"),
 		genStylesheetElement("main"),
 		genStylesheetElement("index"),
 		genTopBar(request),
 		`<h1 class="front-page">main page</h1>`,
+		next,
 		genPageEnd())
 }
 
-func loginHandler(year int, writer http.ResponseWriter, request *http.Request) error {
-	db, err := data.ConnectToDatabase(year)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
+func loginHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
 	if user := db.GetUser(request); user != nil {
 		http.Redirect(writer, request, "/", http.StatusFound)
 		return nil
@@ -109,7 +143,7 @@ func loginHandler(year int, writer http.ResponseWriter, request *http.Request) e
 			errorText = "Invalid username or password."
 			// then continue on to normal page delivery
 		} else {
-			userCookie, err := db.Login(username, password)
+			userCookie, err := db.Login(username, password, requestDevice(request))
 			if err == nil {
 				http.SetCookie(writer, userCookie)
 				http.Redirect(writer, request, "/", http.StatusFound)
@@ -141,19 +175,15 @@ func loginHandler(year int, writer http.ResponseWriter, request *http.Request) e
 		<label>Login</label>
 		%s
 		%s
+		%s
 		<input type="submit" value="Login">
 	</form>
-</div>`, errorText, genTextInput("username", usernameFiller, "Username", true), genPasswordForm("password", "Password")),
+</div>`, errorText, genTextInput("username", usernameFiller, "Username", true), genPasswordForm("password", "Password"),
+			genTextInput("device", "", "Device Name (optional)", false)),
 		genPageEnd())
 }
 
-func signupHandler(year int, writer http.ResponseWriter, request *http.Request) error {
-	db, err := data.ConnectToDatabase(year)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
+func signupHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
 	if user := db.GetUser(request); user != nil {
 		http.Redirect(writer, request, "/", http.StatusFound)
 		return nil
@@ -166,6 +196,7 @@ func signupHandler(year int, writer http.ResponseWriter, request *http.Request)
 	username := ""
 	realname := ""
 	var team int64 = -1
+	var err error
 	if request.Method == "POST" {
 		username = request.PostFormValue("username")
 		realname = request.PostFormValue("realname")
@@ -203,7 +234,8 @@ func signupHandler(year int, writer http.ResponseWriter, request *http.Request)
 			return nil
 		}
 
-		cookie, err := db.CreateUser(username, realname, password, int(team), adminUsername, adminPassword)
+		cookie, err := db.CreateUser(username, realname, password, int(team), adminUsername, adminPassword,
+			requestDevice(request))
 		if err == nil {
 			http.SetCookie(writer, cookie)
 			http.Redirect(writer, request, "/", http.StatusFound)
@@ -270,13 +302,7 @@ func deliverSignup(writer http.ResponseWriter, errorText, username, realname str
 		genPageEnd())
 }
 
-func logoutHandler(year int, writer http.ResponseWriter, request *http.Request) error {
-	db, err := data.ConnectToDatabase(year)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
+func logoutHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
 	db.Logout(writer, request)
 	http.Redirect(writer, request, "/", http.StatusFound)
 	return nil
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"scout/data"
+	"strings"
+	"testing"
+)
+
+func TestSafeHandlerNoSeason(t *testing.T) {
+	if err := data.LoadSeasons("seasons"); err != nil {
+		t.Fatal(err)
+	}
+	data.SetBackend(data.NewMemory())
+	t.Cleanup(func() { data.CloseDatabases() })
+
+	handler := safeHandler(func(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+		_, err := data.GetSeason(year)
+		return err
+	})
+	writer := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Host = "2017.localhost"
+	handler.ServeHTTP(writer, request)
+	if writer.Code != http.StatusOK {
+		t.Errorf("a year with a season got status %d", writer.Code)
+	}
+
+	data.LoadSeasons(t.TempDir())
+	t.Cleanup(func() { data.LoadSeasons("seasons") })
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, request)
+	if writer.Code != http.StatusNotFound || !strings.Contains(writer.Body.String(), data.SeasonFile(2017)) {
+		t.Errorf("a year without a season got status %d and %q", writer.Code, writer.Body.String())
+	}
+	if !strings.HasSuffix(data.SeasonFile(2017), "2017.json") {
+		t.Errorf("SeasonFile(2017) = %q", data.SeasonFile(2017))
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"html"
 	"net/http"
 )
 
@@ -33,11 +34,11 @@ func genPageEnd() string {
 }
 
 func genStylesheetElement(name string) string {
-	return fmt.Sprintf(`<link href="%s.css" rel="stylesheet" type="text/css"/>`, name)
+	return fmt.Sprintf(`<link href="/%s.css" rel="stylesheet" type="text/css"/>`, name)
 }
 
 func genScriptElement(name string) string {
-	return fmt.Sprintf(`<script src="%s.js" type="text/javascript"></script>`, name)
+	return fmt.Sprintf(`<script src="/%s.js" type="text/javascript"></script>`, name)
 }
 
 func genTopBar(request *http.Request) string {
@@ -75,3 +76,30 @@ func genTeamNumberForm(value int) string {
 	}
 	return fmt.Sprintf(`<input name="team-number" %s placeholder="Team Number" type="number" min="1" step="1">`, valueAttribute)
 }
+
+func genNumberInput(name string, value, min, max int) string {
+	return fmt.Sprintf(`<input name="%s" value="%d" type="number" min="%d" max="%d" step="1">`, name, value, min, max)
+}
+
+func genCheckbox(name string, checked bool) string {
+	checkedAttribute := ""
+	if checked {
+		checkedAttribute = "checked"
+	}
+	return fmt.Sprintf(`<input name="%s" type="checkbox" value="1" %s>`, name, checkedAttribute)
+}
+
+// genSelect creates a drop down menu.  options are given as value/label
+// pairs.
+func genSelect(name, selected string, options ...string) string {
+	optionElements := ""
+	for i := 0; i+1 < len(options); i += 2 {
+		selectedAttribute := ""
+		if options[i] == selected {
+			selectedAttribute = "selected"
+		}
+		optionElements += fmt.Sprintf(`<option value="%s" %s>%s</option>`,
+			html.EscapeString(options[i]), selectedAttribute, html.EscapeString(options[i+1]))
+	}
+	return fmt.Sprintf(`<select name="%s">%s</select>`, name, optionElements)
+}
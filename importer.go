@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"scout/data"
+	"time"
+)
+
+// defaultSyncInterval is how often competitions underway are imported again
+// when SCOUT_SYNC_INTERVAL isn't set
+const defaultSyncInterval = 5 * time.Minute
+
+var (
+	// sources maps the name of every configured source of official
+	// competition data to it
+	sources = map[string]data.Source{}
+	// sourceNames lists the configured sources in the order they're offered
+	sourceNames []string
+	// sourceLabels describes each kind of source to admins
+	sourceLabels = map[string]string{
+		"tba": "The Blue Alliance",
+		"frc": "FRC Events",
+	}
+)
+
+// setupSources configures the sources of official competition data from the
+// environment.  The Blue Alliance is used when SCOUT_TBA_KEY holds a read
+// key, and the FRC Events API when SCOUT_FRC_USERNAME and SCOUT_FRC_KEY hold
+// the credentials of an API account.  SCOUT_TBA_URL and SCOUT_FRC_URL may
+// point either client somewhere other than the real API.
+func setupSources() {
+	if key := os.Getenv("SCOUT_TBA_KEY"); key != "" {
+		addSource(data.NewTBA(envOr("SCOUT_TBA_URL", data.TBABaseURL), key))
+	}
+	username, key := os.Getenv("SCOUT_FRC_USERNAME"), os.Getenv("SCOUT_FRC_KEY")
+	if username != "" && key != "" {
+		addSource(data.NewFRCEvents(envOr("SCOUT_FRC_URL", data.FRCEventsBaseURL), username, key))
+	}
+}
+
+func addSource(source data.Source) {
+	sources[source.Name()] = source
+	sourceNames = append(sourceNames, source.Name())
+}
+
+// envOr returns the value of an environment variable, or def if it's unset
+func envOr(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// syncCompetitions imports the competitions underway from their sources
+// every SCOUT_SYNC_INTERVAL, such as 10m, for as long as the server runs
+func syncCompetitions() error {
+	if len(sources) == 0 {
+		return nil
+	}
+	interval := defaultSyncInterval
+	if s := os.Getenv("SCOUT_SYNC_INTERVAL"); s != "" {
+		var err error
+		if interval, err = time.ParseDuration(s); err != nil || interval <= 0 {
+			return fmt.Errorf("invalid SCOUT_SYNC_INTERVAL %q", s)
+		}
+	}
+
+	go func() {
+		for now := range time.Tick(interval) {
+			db, err := data.GetDatabase(now.Year())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "syncCompetitions: "+err.Error())
+				continue
+			}
+			if err = db.SyncActiveCompetitions(sources, now.UTC()); err != nil {
+				fmt.Fprintln(os.Stderr, "syncCompetitions: "+err.Error())
+			}
+		}
+	}()
+	return nil
+}
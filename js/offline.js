@@ -0,0 +1,203 @@
+// queues submissions in local storage so scouting works without a connection,
+// and syncs them to the server whenever it can be reached
+
+var QUEUE_KEY = "scout-queue";
+var REJECTED_KEY = "scout-rejected";
+
+if ("serviceWorker" in navigator) {
+	navigator.serviceWorker.register("/sw.js");
+}
+
+function loadList(key) {
+	try {
+		return JSON.parse(localStorage.getItem(key)) || [];
+	} catch (e) {
+		return [];
+	}
+}
+
+function saveList(key, list) {
+	localStorage.setItem(key, JSON.stringify(list));
+}
+
+function newClientId() {
+	if (window.crypto && crypto.randomUUID) {
+		return crypto.randomUUID();
+	}
+	return Date.now().toString(36) + "-" + Math.random().toString(36).slice(2);
+}
+
+// readSubmission builds a submission the same way the server reads a posted
+// submission form
+function readSubmission(form) {
+	var sub = {
+		clientId: newClientId(),
+		competition: form.elements["competition"].value,
+		level: form.elements["level"].value,
+		match: parseInt(form.elements["match"].value, 10) || 0,
+		team: parseInt(form.elements["team-number"].value, 10) || 0,
+		alliance: form.elements["alliance"].value,
+		values: {}
+	};
+	form.querySelectorAll(".submit-fields [name]").forEach(function (input) {
+		if (input.type === "checkbox") {
+			sub.values[input.name] = input.checked ? "1" : "0";
+		} else {
+			sub.values[input.name] = input.value;
+		}
+	});
+	return sub;
+}
+
+function showSyncStatus(text) {
+	var queued = loadList(QUEUE_KEY).length;
+	var rejected = loadList(REJECTED_KEY).length;
+	var status = text || "";
+	if (queued > 0) {
+		status += " " + queued + " submission" + (queued === 1 ? "" : "s") + " waiting to sync.";
+	}
+	if (rejected > 0) {
+		status += " " + rejected + " submission" + (rejected === 1 ? " was" : "s were") + " rejected and kept on this device.";
+	}
+	document.getElementById("sync-status").textContent = status.trim();
+}
+
+// MAX_SYNC_SIZE is the largest request, in bytes, the server takes at once;
+// it's maxSyncSize in sync.go
+var MAX_SYNC_SIZE = 1 << 20;
+
+function byteLength(text) {
+	return new Blob([text]).size;
+}
+
+// nextBatch takes as many submissions from the front of the queue as can be
+// synced in one request
+function nextBatch(queue) {
+	var batch = [];
+	var size = byteLength(JSON.stringify({submissions: []}));
+	for (var i = 0; i < queue.length; i++) {
+		size += byteLength(JSON.stringify(queue[i])) + (batch.length > 0 ? 1 : 0);
+		if (size >= MAX_SYNC_SIZE) {
+			break;
+		}
+		batch.push(queue[i]);
+	}
+	return batch;
+}
+
+// syncFailure explains why the server refused a sync
+function syncFailure(status) {
+	if (status === 401) {
+		return "Log in again to sync.";
+	}
+	if (status === 413) {
+		return "The submissions were too large to sync.";
+	}
+	return "Syncing failed (error " + status + "); trying again soon.";
+}
+
+var syncing = false;
+
+function sync() {
+	var queue = loadList(QUEUE_KEY);
+	if (syncing || queue.length === 0) {
+		showSyncStatus();
+		return;
+	}
+	var batch = nextBatch(queue);
+	if (batch.length === 0) {
+		// the first submission could never be synced, so set it aside
+		var rejected = loadList(REJECTED_KEY);
+		rejected.push({submission: queue[0], message: "too large to sync"});
+		saveList(REJECTED_KEY, rejected);
+		saveList(QUEUE_KEY, queue.slice(1));
+		sync();
+		return;
+	}
+	syncing = true;
+	var more = false;
+	fetch("/sync", {
+		method: "POST",
+		credentials: "same-origin",
+		headers: {"Content-Type": "application/json"},
+		body: JSON.stringify({submissions: batch})
+	}).then(function (response) {
+		if (!response.ok) {
+			var failure = new Error(syncFailure(response.status));
+			failure.status = response.status;
+			throw failure;
+		}
+		return response.json();
+	}).then(function (response) {
+		var sent = {};
+		batch.forEach(function (sub) {
+			sent[sub.clientId] = sub;
+		});
+		var finished = {};
+		var rejected = loadList(REJECTED_KEY);
+		var messages = [];
+		response.results.forEach(function (result) {
+			finished[result.clientId] = true;
+			if (result.status === "conflict" || result.status === "invalid") {
+				if (!Object.prototype.hasOwnProperty.call(sent, result.clientId)) {
+					// the server answered for something that wasn't sent
+					return;
+				}
+				var sub = sent[result.clientId];
+				rejected.push({submission: sub, message: result.message});
+				messages.push("Team " + sub.team + " in match " + sub.match + ": " + result.message + ".");
+			}
+		});
+		saveList(REJECTED_KEY, rejected);
+		// keep anything queued while the sync was in flight
+		var remaining = loadList(QUEUE_KEY).filter(function (sub) {
+			return !finished[sub.clientId];
+		});
+		saveList(QUEUE_KEY, remaining);
+		// sync the rest of the queue, as long as this batch got somewhere
+		more = remaining.length > 0 && response.results.length > 0;
+		showSyncStatus(messages.join(" "));
+	}).catch(function (err) {
+		// fetch itself only fails when the server can't be reached
+		showSyncStatus(err.status ? err.message : "Offline.");
+	}).then(function () {
+		syncing = false;
+		if (more) {
+			sync();
+		}
+	});
+}
+
+function offlineSetup() {
+	var form = document.forms["submit"];
+	if (!form) {
+		return;
+	}
+	form.addEventListener("submit", function (event) {
+		if (event.submitter && event.submitter.getAttribute("formaction") === "/qr") {
+			return; // the server packs the submission into a QR code instead
+		}
+		event.preventDefault();
+		var sub = readSubmission(form);
+		var queue = loadList(QUEUE_KEY);
+		queue.push(sub);
+		saveList(QUEUE_KEY, queue);
+
+		// start the next submission on the following match
+		form.reset();
+		form.elements["competition"].value = sub.competition;
+		form.elements["level"].value = sub.level;
+		form.elements["alliance"].value = sub.alliance;
+		form.elements["match"].value = sub.match + 1;
+		form.elements["team-number"].value = "";
+		document.querySelector(".submit-message").textContent =
+			"Queued team " + sub.team + " in match " + sub.match;
+		sync();
+	});
+
+	window.addEventListener("online", sync);
+	setInterval(sync, 30000);
+	sync();
+}
+
+document.addEventListener("DOMContentLoaded", offlineSetup);
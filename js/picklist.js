@@ -0,0 +1,80 @@
+// drag-to-reorder pick lists and live tracking of alliance selection picks
+
+function post(url, params) {
+	return fetch(url, {
+		method: "POST",
+		credentials: "same-origin",
+		headers: {"Content-Type": "application/x-www-form-urlencoded"},
+		body: new URLSearchParams(params).toString()
+	});
+}
+
+function pickListSetup() {
+	var list = document.getElementById("pick-list");
+	if (list === null) {
+		return;
+	}
+	var listId = list.dataset.list;
+	var competition = list.dataset.competition;
+	var dragged = null;
+
+	function saveOrder() {
+		var teams = [];
+		list.querySelectorAll("li[data-team]").forEach(function (entry) {
+			teams.push(entry.dataset.team);
+		});
+		post("/picklists/" + listId, {action: "order", teams: teams.join(",")});
+	}
+
+	function markPicked(picked) {
+		list.querySelectorAll("li[data-team]").forEach(function (entry) {
+			var isPicked = picked.indexOf(parseInt(entry.dataset.team, 10)) !== -1;
+			entry.classList.toggle("pick-list-picked", isPicked);
+			entry.querySelector(".pick-list-pick").textContent = isPicked ? "Unpick" : "Picked";
+		});
+	}
+
+	function refreshPicked() {
+		fetch("/picklists/picked?competition=" + encodeURIComponent(competition), {credentials: "same-origin"})
+			.then(function (response) { return response.json(); })
+			.then(markPicked);
+	}
+
+	list.querySelectorAll("li[data-team]").forEach(function (entry) {
+		entry.addEventListener("dragstart", function (event) {
+			dragged = entry;
+			event.dataTransfer.effectAllowed = "move";
+		});
+		entry.addEventListener("dragover", function (event) {
+			event.preventDefault();
+			if (dragged === null || dragged === entry) {
+				return;
+			}
+			var box = entry.getBoundingClientRect();
+			if (event.clientY < box.top + box.height / 2) {
+				list.insertBefore(dragged, entry);
+			} else {
+				list.insertBefore(dragged, entry.nextSibling);
+			}
+		});
+		entry.addEventListener("dragend", function () {
+			dragged = null;
+			saveOrder();
+		});
+		entry.querySelector(".pick-list-pick").addEventListener("click", function () {
+			var picked = entry.classList.contains("pick-list-picked") ? "0" : "1";
+			post("/picklists/picked", {competition: competition, team: entry.dataset.team, picked: picked})
+				.then(function (response) { return response.json(); })
+				.then(markPicked);
+		});
+	});
+
+	document.getElementById("show-picked").addEventListener("change", function (event) {
+		list.classList.toggle("pick-list-show-picked", event.target.checked);
+	});
+
+	refreshPicked();
+	setInterval(refreshPicked, 5000);
+}
+
+document.addEventListener("DOMContentLoaded", pickListSetup);
@@ -0,0 +1,51 @@
+// service worker that keeps the submit page and its resources available
+// offline.  Everything is fetched from the network first so a deploy is
+// picked up right away, and the cache is only used when that fails.
+
+var CACHE = "scout-v1";
+var RESOURCES = ["/main.css", "/topbar.css", "/submit.css", "/offline.js"];
+
+self.addEventListener("install", function (event) {
+	event.waitUntil(caches.open(CACHE).then(function (cache) {
+		return cache.addAll(RESOURCES);
+	}));
+	self.skipWaiting();
+});
+
+self.addEventListener("activate", function (event) {
+	event.waitUntil(caches.keys().then(function (names) {
+		return Promise.all(names.filter(function (name) {
+			return name !== CACHE;
+		}).map(function (name) {
+			return caches.delete(name);
+		}));
+	}).then(function () {
+		return self.clients.claim();
+	}));
+});
+
+function cacheable(url) {
+	return url.origin === self.location.origin &&
+		(url.pathname === "/submit" || RESOURCES.indexOf(url.pathname) !== -1);
+}
+
+self.addEventListener("fetch", function (event) {
+	var url = new URL(event.request.url);
+	if (event.request.method !== "GET" || !cacheable(url)) {
+		return;
+	}
+	event.respondWith(fetch(event.request).then(function (response) {
+		// a redirect to the login page shouldn't replace the cached form
+		if (response.ok && !response.redirected) {
+			var copy = response.clone();
+			caches.open(CACHE).then(function (cache) {
+				cache.put(url.pathname, copy);
+			});
+		}
+		return response;
+	}).catch(function () {
+		return caches.match(url.pathname).then(function (cached) {
+			return cached || Response.error();
+		});
+	}));
+});
@@ -1,15 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"scout/data"
+	"strconv"
+	"syscall"
+	"time"
 )
 
 const (
 	exitSuccess = iota
 	exitCacheError
 	exitServeError
+	exitSeasonError
+	exitSyncError
+	exitUsageError
+	exitImportError
+	exitBackendError
+	exitMigrateError
 )
 
 func main() {
@@ -20,17 +32,106 @@ func main() {
 }
 
 func program() int {
-	err := cacheStaticPages()
+	if err := setupBackend(); err != nil {
+		fmt.Fprintln(os.Stderr, "backend error: "+err.Error())
+		return exitBackendError
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		return importCommand(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return migrateCommand(os.Args[2:])
+	}
+
+	// earlier years are migrated with scout migrate, since they're rarely used
+	_, err := data.Migrate(time.Now().Year(), false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migration error: "+err.Error())
+		return exitMigrateError
+	}
+	err = cacheStaticPages()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "caching error: "+err.Error())
 		return exitCacheError
 	}
+	err = data.LoadSeasons("seasons")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "season error: "+err.Error())
+		return exitSeasonError
+	}
+	if _, err = data.GetSeason(time.Now().Year()); err != nil {
+		// every page of the year needs its season, but earlier years still work
+		fmt.Fprintln(os.Stderr, "season warning: "+data.SeasonFile(time.Now().Year())+" is missing")
+	}
+	setupSources()
+	err = syncCompetitions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sync error: "+err.Error())
+		return exitSyncError
+	}
 	setupHandlers()
 
-	err = http.ListenAndServe(":80", nil)
+	err = serve(":80")
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "hosting error: "+err.Error())
 		return exitServeError
 	}
 	return 0
 }
+
+// serve hosts the handlers until the program is interrupted or terminated,
+// then lets the requests underway finish and closes the databases
+func serve(addr string) error {
+	server := &http.Server{Addr: addr}
+	stopped := make(chan error, 1)
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		stopped <- server.Shutdown(ctx)
+	}()
+
+	err := server.ListenAndServe()
+	if err != http.ErrServerClosed {
+		data.CloseDatabases()
+		return err
+	}
+	err = <-stopped
+	if closeErr := data.CloseDatabases(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// setupBackend chooses where the databases are stored from the environment.
+// SCOUT_DB names the kind of backend, which is mysql unless it's sqlite or
+// memory, and SCOUT_DB_LOCATION may tell it where to keep them.
+// SCOUT_DB_MAX_OPEN and SCOUT_DB_MAX_IDLE limit the connections kept to the
+// database of each year.
+func setupBackend() error {
+	backend, err := data.NewBackend(envOr("SCOUT_DB", "mysql"), os.Getenv("SCOUT_DB_LOCATION"))
+	if err != nil {
+		return err
+	}
+	data.SetBackend(backend)
+
+	config := data.DefaultPoolConfig
+	limits := []struct {
+		name  string
+		limit *int
+	}{
+		{"SCOUT_DB_MAX_OPEN", &config.MaxOpen},
+		{"SCOUT_DB_MAX_IDLE", &config.MaxIdle},
+	}
+	for _, limit := range limits {
+		if s := os.Getenv(limit.name); s != "" {
+			if *limit.limit, err = strconv.Atoi(s); err != nil || *limit.limit < 0 {
+				return fmt.Errorf("invalid %s %q", limit.name, s)
+			}
+		}
+	}
+	data.SetPoolConfig(config)
+	return nil
+}
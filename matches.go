@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+)
+
+func matchesHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+	competition := request.FormValue("competition")
+	predictions, err := db.PredictMatches(competition)
+	if err != nil {
+		return err
+	}
+
+	competitionOptions := []string{"", "All Competitions"}
+	for _, comp := range competitions {
+		competitionOptions = append(competitionOptions, comp.Key, comp.Name)
+	}
+
+	rows := ""
+	for _, predicted := range predictions {
+		match := predicted.Match
+		score := ""
+		if match.Scored {
+			score = fmt.Sprintf(`<span class="alliance-red">%d</span> - <span class="alliance-blue">%d</span>`,
+				match.RedScore, match.BlueScore)
+		}
+		coverage := match.Coverage()
+		coverageClass := "coverage-full"
+		if coverage < 6 {
+			coverageClass = "coverage-gap"
+		}
+		rows += fmt.Sprintf(`
+	<tr><td>%s</td><td>%s%d</td><td class="alliance-red">%s</td><td class="alliance-blue">%s</td><td>%s</td><td>%s</td><td class="%s">%d/6</td></tr>`,
+			html.EscapeString(match.Competition), match.Level.Abbreviation(), match.Number,
+			genMatchTeams(match, match.Red), genMatchTeams(match, match.Blue),
+			genPrediction(predicted), score, coverageClass, coverage)
+	}
+
+	return writeAll(writer,
+		genPageStart("Matches"),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genStylesheetElement("matches"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Matches</h1>
+<form class="match-filter" action="matches" method="get">
+	%s
+	<input type="submit" value="Show">
+</form>
+<table class="team-table">
+	<tr><th>Competition</th><th>Match</th><th>Red</th><th>Blue</th><th>Predicted</th><th>Score</th><th>Scouted</th></tr>%s
+</table>`, genSelect("competition", competition, competitionOptions...), rows),
+		genPageEnd())
+}
+
+// genMatchTeams lists the teams of one alliance, marking the ones that haven't
+// been scouted yet
+func genMatchTeams(match data.Match, teams []int) string {
+	list := ""
+	for _, team := range teams {
+		class := "team-scouted"
+		if match.Scouted[team] == 0 {
+			class = "team-unscouted"
+		}
+		list += fmt.Sprintf(`<span class="%s">%s</span> `, class, genTeamLink(team))
+	}
+	return list
+}
+
+// genPrediction describes the predicted outcome of a match.  Once the match
+// has been scored, the prediction is marked by whether it picked the winner.
+func genPrediction(predicted data.MatchPrediction) string {
+	prediction := predicted.Prediction
+	winner := prediction.Winner()
+	chance := prediction.RedWinProbability
+	if winner == data.Blue {
+		chance = 1 - chance
+	}
+
+	class := "prediction"
+	match := predicted.Match
+	if match.Scored && match.RedScore != match.BlueScore {
+		actual := data.Red
+		if match.BlueScore > match.RedScore {
+			actual = data.Blue
+		}
+		if actual == winner {
+			class = "prediction-correct"
+		} else {
+			class = "prediction-wrong"
+		}
+	}
+
+	unscouted := ""
+	if prediction.Unscouted > 0 {
+		unscouted = fmt.Sprintf(` <span class="prediction-unscouted">(%d unscouted)</span>`, prediction.Unscouted)
+	}
+	return fmt.Sprintf(`<span class="%s"><span class="alliance-red">%.0f</span> - <span class="alliance-blue">%.0f</span>, %s %.0f%%</span>%s`,
+		class, prediction.RedScore, prediction.BlueScore, winner, chance*100, unscouted)
+}
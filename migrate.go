@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"scout/data"
+	"time"
+)
+
+// migrateCommand brings the database of a year up to date from the command
+// line, creating it if it's a new year:
+//
+//	scout migrate [-year N] [-dry-run]
+//
+// A dry run prints the SQL of every migration the database hasn't had yet
+// without changing anything.
+func migrateCommand(args []string) int {
+	commands := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	year := commands.Int("year", time.Now().Year(), "the `year` whose database to migrate")
+	dryRun := commands.Bool("dry-run", false, "print the migrations that would be applied")
+	commands.Usage = func() {
+		fmt.Fprintln(commands.Output(), "usage: scout migrate [flags]")
+		commands.PrintDefaults()
+	}
+	if commands.Parse(args) != nil || commands.NArg() != 0 {
+		if commands.NArg() != 0 {
+			commands.Usage()
+		}
+		return exitUsageError
+	}
+
+	migrations, err := data.Migrate(*year, *dryRun)
+	for _, migration := range migrations {
+		fmt.Printf("%04d_%s\n", migration.Version, migration.Name)
+		if *dryRun {
+			for _, statement := range migration.Statements() {
+				fmt.Println(statement)
+			}
+			fmt.Println()
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migration error: "+err.Error())
+		return exitMigrateError
+	}
+	if len(migrations) == 0 {
+		fmt.Printf("the %d database is up to date\n", *year)
+	}
+	return exitSuccess
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"scout/data"
+	"strconv"
+	"strings"
+)
+
+// photosHandler accepts photo uploads posted to /photos, and serves each
+// photo at /photos/{id} and its thumbnail at /photos/{id}/thumb
+func photosHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	path := strings.Trim(strings.TrimPrefix(request.URL.Path, "/photos"), "/")
+	if path == "" {
+		if request.Method != "POST" {
+			return data.ErrHTTPMethodUnsupported
+		}
+		return uploadPhoto(db, writer, request)
+	}
+	if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	thumb := strings.HasSuffix(path, "/thumb")
+	id, err := strconv.ParseInt(strings.TrimSuffix(path, "/thumb"), 10, 64)
+	if err != nil {
+		return data.ErrNotFound
+	}
+	photo, err := db.GetPhoto(id)
+	if err != nil {
+		return err
+	}
+
+	name, contentType := photo.Path(year), photo.ContentType
+	if thumb {
+		name, contentType = photo.ThumbnailPath(year), "image/jpeg"
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return data.ErrPhotoNotFound
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// photos never change once uploaded
+	writer.Header().Set("Content-Type", contentType)
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writer.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(writer, request, "", info.ModTime(), f)
+	return nil
+}
+
+func uploadPhoto(db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	user := db.GetUser(request)
+	if user == nil {
+		return data.ErrAccessDenied
+	}
+
+	// leave room for the rest of the form alongside the photo itself
+	request.Body = http.MaxBytesReader(writer, request.Body, data.MaxPhotoSize+1<<20)
+	if err := request.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return data.ErrPhotoTooLarge
+		}
+		return data.ErrMalformedRequest
+	}
+	defer request.MultipartForm.RemoveAll()
+
+	team, err := strconv.Atoi(request.FormValue("team-number"))
+	if err != nil {
+		return data.ErrInvalidTeam
+	}
+	f, _, err := request.FormFile("photo")
+	if err != nil {
+		return data.ErrMalformedRequest
+	}
+	defer f.Close()
+
+	if _, err = db.SavePhoto(user, team, request.FormValue("competition"), f); err != nil {
+		return err
+	}
+	http.Redirect(writer, request, fmt.Sprintf("/teams/%d", team), http.StatusFound)
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+	"strconv"
+	"strings"
+)
+
+// picklistsHandler serves the pick lists of a competition at /picklists, a
+// single list at /picklists/{id}, and the teams picked during alliance
+// selection at /picklists/picked.  Only strategists may use any of them.
+func picklistsHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	user := db.GetUser(request)
+	if user == nil && request.Method == "GET" {
+		http.Redirect(writer, request, "/login", http.StatusFound)
+		return nil
+	}
+	if !user.CanStrategize() {
+		return data.ErrAccessDenied
+	}
+
+	if request.ParseForm() != nil {
+		return data.ErrMalformedRequest
+	}
+
+	path := strings.Trim(strings.TrimPrefix(request.URL.Path, "/picklists"), "/")
+	switch path {
+	case "":
+		return handlePickLists(db, user, writer, request)
+	case "picked":
+		return handlePicked(db, user, writer, request)
+	}
+
+	id, err := strconv.ParseInt(path, 10, 64)
+	if err != nil {
+		return data.ErrNotFound
+	}
+	return handlePickList(db, user, id, writer, request)
+}
+
+func handlePickLists(db data.DB, user *data.User, writer http.ResponseWriter, request *http.Request) error {
+	competition := request.FormValue("competition")
+	message := ""
+	if request.Method == "POST" {
+		id, err := db.CreatePickList(user, competition, request.PostFormValue("name"))
+		if err == nil {
+			http.Redirect(writer, request, fmt.Sprintf("/picklists/%d", id), http.StatusFound)
+			return nil
+		} else if err != data.ErrInvalidPickList {
+			return err
+		}
+		message = "Give the pick list a name"
+	} else if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+	if competition == "" && len(competitions) > 0 {
+		competition = competitions[len(competitions)-1].Key
+	}
+	competitionOptions := make([]string, 0, 2*len(competitions))
+	for _, comp := range competitions {
+		competitionOptions = append(competitionOptions, comp.Key, comp.Name)
+	}
+
+	lists, err := db.GetPickLists(competition)
+	if err != nil {
+		return err
+	}
+	items := ""
+	for _, list := range lists {
+		items += fmt.Sprintf(`
+	<li><a href="/picklists/%d">%s</a></li>`, list.Id, html.EscapeString(list.Name))
+	}
+	if items == "" {
+		items = `
+	<li>No pick lists yet</li>`
+	}
+
+	return writeAll(writer,
+		genPageStart("Pick Lists"),
+		genStylesheetElement("main"),
+		genStylesheetElement("picklist"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Pick Lists</h1>
+<form class="pick-list-form" action="picklists" method="get">
+	%s
+	<input type="submit" value="Show">
+</form>
+<ul class="pick-lists">%s
+</ul>
+<span class="pick-list-message">%s</span>
+<form class="pick-list-form" action="picklists" method="post">
+	<input name="competition" value="%s" type="hidden">
+	%s
+	<input type="submit" value="New Pick List">
+</form>`,
+			genSelect("competition", competition, competitionOptions...), items, message,
+			html.EscapeString(competition), genTextInput("name", "", "List Name", true)),
+		genPageEnd())
+}
+
+func handlePickList(db data.DB, user *data.User, id int64, writer http.ResponseWriter, request *http.Request) error {
+	if request.Method == "POST" {
+		var err error
+		switch request.PostFormValue("action") {
+		case "order":
+			teams := []int{}
+			for _, s := range strings.Split(request.PostFormValue("teams"), ",") {
+				team, err := strconv.Atoi(s)
+				if err != nil {
+					return data.ErrMalformedRequest
+				}
+				teams = append(teams, team)
+			}
+			if err = db.ReorderPickList(user, id, teams); err != nil {
+				return err
+			}
+			writer.WriteHeader(http.StatusNoContent)
+			return nil
+		case "annotate":
+			team, _ := strconv.Atoi(request.PostFormValue("team"))
+			err = db.AnnotatePickList(user, id, team, request.PostFormValue("note"), request.PostFormValue("do-not-pick") == "1")
+		case "delete":
+			if err = db.DeletePickList(user, id); err != nil {
+				return err
+			}
+			http.Redirect(writer, request, "/picklists", http.StatusFound)
+			return nil
+		default:
+			return data.ErrMalformedRequest
+		}
+		if err != nil {
+			return err
+		}
+		http.Redirect(writer, request, fmt.Sprintf("/picklists/%d", id), http.StatusFound)
+		return nil
+	} else if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	list, err := db.GetPickList(id)
+	if err != nil {
+		return err
+	}
+
+	entries := ""
+	for _, entry := range list.Entries {
+		class := "pick-list-entry"
+		if entry.DoNotPick {
+			class += " pick-list-do-not-pick"
+		}
+		if entry.Picked {
+			class += " pick-list-picked"
+		}
+		entries += fmt.Sprintf(`
+	<li class="%s" draggable="true" data-team="%d">
+		<span class="pick-list-team">%s</span>
+		<form class="pick-list-note" action="/picklists/%d" method="post">
+			<input name="action" value="annotate" type="hidden">
+			<input name="team" value="%d" type="hidden">
+			%s
+			<label>Do not pick %s</label>
+			<input type="submit" value="Save">
+		</form>
+		<button class="pick-list-pick" type="button">Picked</button>
+	</li>`, class, entry.Team, genTeamLink(entry.Team), list.Id, entry.Team,
+			genTextInput("note", html.EscapeString(entry.Note), "Note", false),
+			genCheckbox("do-not-pick", entry.DoNotPick))
+	}
+
+	return writeAll(writer,
+		genPageStart(list.Name),
+		genStylesheetElement("main"),
+		genStylesheetElement("picklist"),
+		genScriptElement("picklist"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>%s <span class="pick-list-competition">%s</span></h1>
+<label class="pick-list-form">Show picked teams <input id="show-picked" type="checkbox"></label>
+<ol id="pick-list" class="pick-list" data-list="%d" data-competition="%s">%s
+</ol>
+<form class="pick-list-form" action="/picklists/%d" method="post" onsubmit="return confirm('Delete this pick list?');">
+	<input name="action" value="delete" type="hidden">
+	<input type="submit" value="Delete Pick List">
+</form>`, html.EscapeString(list.Name), html.EscapeString(list.Competition),
+			list.Id, html.EscapeString(list.Competition), entries, list.Id),
+		genPageEnd())
+}
+
+// handlePicked reports the teams already picked at a competition as a JSON
+// array, or marks a team as picked or not when posted to
+func handlePicked(db data.DB, user *data.User, writer http.ResponseWriter, request *http.Request) error {
+	competition := request.FormValue("competition")
+	if request.Method == "POST" {
+		team, err := strconv.Atoi(request.PostFormValue("team"))
+		if err != nil {
+			return data.ErrMalformedRequest
+		}
+		err = db.SetPicked(user, competition, team, request.PostFormValue("picked") == "1")
+		if err != nil {
+			return err
+		}
+	} else if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	picked, err := db.GetPickedTeams(competition)
+	if err != nil {
+		return err
+	}
+	teams := make([]int, 0, len(picked))
+	for team := range picked {
+		teams = append(teams, team)
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(writer).Encode(teams)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+	"strconv"
+)
+
+// pitHandler records what each team's robot is like, one record per team per
+// competition
+func pitHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	season, err := data.GetSeason(year)
+	if err != nil {
+		return err
+	}
+
+	user := db.GetUser(request)
+	if user == nil {
+		if request.Method == "GET" {
+			http.Redirect(writer, request, "/login", http.StatusFound)
+			return nil
+		}
+		return data.ErrAccessDenied
+	}
+
+	if request.ParseForm() != nil {
+		return data.ErrMalformedRequest
+	}
+
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+
+	team, _ := strconv.ParseInt(request.FormValue("team-number"), 10, 16)
+	record := data.PitRecord{
+		Competition: request.FormValue("competition"),
+		Team:        int(team),
+		Values:      map[string]string{},
+	}
+	if request.Method == "POST" {
+		record.Values = readFieldValues(request, season.PitFields)
+		err = db.SavePitRecord(user, &record)
+		if err == nil {
+			message := fmt.Sprintf("Saved team %d", record.Team)
+			next := data.PitRecord{Competition: record.Competition, Values: map[string]string{}}
+			return deliverPit(writer, request, season, competitions, message, next)
+		}
+		message, ok := submissionErrorText(err)
+		if !ok {
+			return err
+		}
+		return deliverPit(writer, request, season, competitions, message, record)
+	} else if request.Method == "GET" {
+		// start from the team's current record if it has one
+		existing, err := db.GetPitRecord(record.Competition, record.Team)
+		if err == nil {
+			record = *existing
+		} else if err != data.ErrPitRecordNotFound {
+			return err
+		}
+		return deliverPit(writer, request, season, competitions, "", record)
+	}
+	return data.ErrHTTPMethodUnsupported
+}
+
+func deliverPit(writer http.ResponseWriter, request *http.Request, season *data.Season, competitions []data.Competition, message string, record data.PitRecord) error {
+	fields := ""
+	for _, field := range season.PitFields {
+		fields += fmt.Sprintf(`
+		<label class="submit-field">%s %s</label>`, field.Label, genFieldInput(field, record.Values[field.Name]))
+	}
+
+	competitionOptions := make([]string, 0, 2*len(competitions))
+	for _, comp := range competitions {
+		competitionOptions = append(competitionOptions, comp.Key, comp.Name)
+	}
+
+	return writeAll(writer,
+		genPageStart("Pit Scouting"),
+		genStylesheetElement("main"),
+		genStylesheetElement("submit"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<span class="submit-message">%s</span>
+<form name="pit" action="pit" method="post" accept-charset="utf-8">
+	<div class="submit-match">
+		<label>Robot</label>
+		%s
+		%s
+	</div>
+	<div class="submit-fields">%s
+	</div>
+	<input type="submit" value="Save">
+</form>`, html.EscapeString(message),
+			genSelect("competition", record.Competition, competitionOptions...),
+			genTeamNumberForm(record.Team), fields),
+		genPageEnd())
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"image/png"
+	"net/http"
+	"net/url"
+	"scout/data"
+)
+
+// qrScale is the width in pixels of each module of a generated QR code
+const qrScale = 6
+
+// qrHandler draws the QR code of a packed submission when given one in the
+// payload parameter, and packs the submission form posted to it into a QR
+// code for a scout to show to whoever is importing submissions
+func qrHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	if request.Method == "GET" {
+		code, err := encodeQR(request.FormValue("payload"))
+		if err != nil {
+			return data.ErrMalformedRequest
+		}
+		writer.Header().Set("Content-Type", "image/png")
+		return png.Encode(writer, code.image(qrScale))
+	} else if request.Method != "POST" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	season, err := data.GetSeason(year)
+	if err != nil {
+		return err
+	}
+
+	user := db.GetUser(request)
+	if user == nil {
+		return data.ErrAccessDenied
+	}
+	if request.ParseForm() != nil {
+		return data.ErrMalformedRequest
+	}
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+
+	sub := data.Submission{ScoutId: user.Id, Values: map[string]string{}}
+	if message := readSubmissionForm(request, season, &sub); message != "" {
+		return deliverSubmit(writer, request, season, competitions, message, sub)
+	}
+	if sub.ClientId, err = data.NewClientId(); err != nil {
+		return err
+	}
+	payload, err := data.EncodeSubmission(season, sub)
+	if err != nil {
+		if message, ok := submissionErrorText(err); ok {
+			return deliverSubmit(writer, request, season, competitions, message, sub)
+		}
+		return err
+	}
+
+	return writeAll(writer,
+		genPageStart("QR Code"),
+		genStylesheetElement("main"),
+		genStylesheetElement("submit"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Team %d in match %s%d</h1>
+<img class="qr-code" src="/qr?payload=%s" alt="QR code of the submission">
+<pre class="qr-payload">%s</pre>
+<a class="submit-message" href="/submit?competition=%s&amp;level=%s&amp;match=%d">Next match</a>`,
+			sub.Team, sub.Level.Abbreviation(), sub.Match, url.QueryEscape(payload), html.EscapeString(payload),
+			url.QueryEscape(sub.Competition), sub.Level, sub.Match+1),
+		genPageEnd())
+}
+
+// qrImportHandler stores submissions read from the QR codes of scouts who
+// couldn't reach the server themselves.  Only strategists may import them.
+func qrImportHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	user := db.GetUser(request)
+	if user == nil && request.Method == "GET" {
+		http.Redirect(writer, request, "/login", http.StatusFound)
+		return nil
+	}
+	if !user.CanStrategize() {
+		return data.ErrAccessDenied
+	}
+
+	message := ""
+	if request.Method == "POST" {
+		if request.ParseForm() != nil {
+			return data.ErrMalformedRequest
+		}
+		var err error
+		if message, err = importPayload(db, user, year, request.PostFormValue("payload")); err != nil {
+			return err
+		}
+	} else if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	return writeAll(writer,
+		genPageStart("Import QR Code"),
+		genStylesheetElement("main"),
+		genStylesheetElement("submit"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Import QR Code</h1>
+<span class="submit-message">%s</span>
+<form class="submit-match" action="/qr/import" method="post">
+	<textarea name="payload" placeholder="Scanned QR code" rows="6" required autofocus></textarea>
+	<input type="submit" value="Import">
+</form>`, html.EscapeString(message)),
+		genPageEnd())
+}
+
+// importPayload stores the submission packed in a scanned QR code and
+// describes what happened to it
+func importPayload(db data.DB, user *data.User, year int, payload string) (string, error) {
+	sub, err := data.DecodeSubmission(payload, year)
+	switch err {
+	case nil:
+	case data.ErrInvalidTransfer:
+		return "The QR code was damaged or incomplete", nil
+	case data.ErrTransferVersion:
+		return "The QR code was made by a different version of the scouting system", nil
+	case data.ErrNoSeason:
+		return fmt.Sprintf("The QR code isn't from the %d season", year), nil
+	default:
+		return "", err
+	}
+
+	result, err := db.ImportSubmission(user, sub)
+	if err != nil {
+		return "", err
+	}
+	match := fmt.Sprintf("team %d in match %s%d", sub.Team, sub.Level.Abbreviation(), sub.Match)
+	switch result.Status {
+	case data.SyncCreated:
+		return "Imported " + match, nil
+	case data.SyncDuplicate:
+		return "Already imported " + match, nil
+	case data.SyncConflict:
+		return fmt.Sprintf("A different submission %d was already saved for this QR code", result.Id), nil
+	}
+	return syncErrorText(result.Err), nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// qrReferences are codes made for the same text by a separate QR encoder,
+// with # for dark modules
+var qrReferences = []struct {
+	text   string
+	matrix string
+}{
+	// version 1, the RFC 9285 base45 example for "Hello!!"
+	{"%69 VD92EX0", `
+	#######..#.##.#######
+	#.....#.#####.#.....#
+	#.###.#....##.#.###.#
+	#.###.#...##..#.###.#
+	#.###.#.#####.#.###.#
+	#.....#...##..#.....#
+	#######.#.#.#.#######
+	.........##..........
+	#.#.#.#..##.#...#..#.
+	..###...####.#.#.#.#.
+	##...##.##.#..#.##.##
+	..###...#..###..#...#
+	##..###..###..####...
+	........##....#..#.##
+	#######...#.#....#.#.
+	#.....#.......###....
+	#.###.#.#.#.#.#....##
+	#.###.#..###.#..##.#.
+	#.###.#.#..#.##...#.#
+	#.....#...####.#.#.##
+	#######.#.##.###..#.#
+`},
+	// version 8, with alignment patterns, version information and blocks of
+	// two lengths
+	{strings.Repeat(qrAlphanumeric, 4), `
+	#######.##.#..#.##.#.#..#.....##.#.##...#.#######
+	#.....#.###..#....#..#.#.##.....##.#.####.#.....#
+	#.###.#...#####.##.##.##..###....#..##.##.#.###.#
+	#.###.#.##..#....#.#..#.###.#...#.##.#.#..#.###.#
+	#.###.#...#.####.#..#.######..##..#.#.....#.###.#
+	#.....#..###...#.....##...####.#.....##...#.....#
+	#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######
+	........##.###.#.#..#.#...##.###.###...#.........
+	#.##.###....#.##...#.########.#.#..#.#..#.#..#.##
+	....#..##.#######..#..###..#.#....#..###...##.##.
+	##.#.####....###..##.###.#.#..#.###.....##.#....#
+	..#....##.###.#.###...#.#.#..##.#.##.####.###..#.
+	#..#.##.####.##.###....##..##.#.#.###...####..#..
+	.###...##.#.##..#..#.##.....###..#.####....#.##..
+	#...#.###.#.##.###.##..##..##.#..#.#..#.#######..
+	##.#.#.#.#..#....#.##.##.#.#.#....#.#######...#.#
+	..#..##.####.#.#...#.....#######..#.#.....#...##.
+	#..##..##.###..#...####.###..#####..#.#.#.#...##.
+	.#.#.####....##.####..#.##..#.####.#....##.###...
+	.#.###.##..####..#....#.##.#..#..####.#####...##.
+	...##.###.###...#.###.#####.......##.##....###.##
+	..##.#..#.##.###.....#...####.##......#####..##.#
+	##..#####..##.#.#...#.######....##..#...#######.#
+	..#.#...###.#....##.###...##.###.#####..#...####.
+	.#.##.#.####...##..#.##.#.#..#.##.##.####.#.##.##
+	..#.#...##.#.#...#.####...##.###.#####.##...###.#
+	....#####.###.#######.#####.###.#..#..########.##
+	....##.#...#.###.#.##..#....#.#.#.#..#..#.#.#...#
+	.##...#.#...#...#..#####..##....##..##..##.###..#
+	#.###..##.##..##...#..###.....##.#..##.#.#.###...
+	...##.#.#..###.#####.#...##.####.##..##.##..#.#.#
+	#..##...#..#...##...#.#.##.#.#.##.....##..#..####
+	#.#.####......####..#.#.##..##.#.##....#...##.#.#
+	.#.#...#.#.#..##...#######....##.#.##..####..##..
+	.#.#####..#..#.##..#....###.....##.#.##..#.#..#.#
+	##.....##....##...#..#.##..#.##...##..#.####..##.
+	#...#.#.####.##..###.#....#.#.#....##.#.###..###.
+	#.#.#..#.#....#.#..###..##.###.#..#...######..#.#
+	.#...##.#.#.#.....#..##.##..###.#####...#.###..##
+	.###.......#.#..####..#.#..##........##..#..#.#.#
+	###...#..#...##..#..#.#####...###..##.#######.#.#
+	........##.#...##.#.###...#.#......##.###...#....
+	#######.##........##.##.#.##..###.#..#.##.#.##.#.
+	#.....#.#.#....##.#.###...##.###....##..#...#.##.
+	#.###.#...#.#..#...#..#####..#.##.##.#.######....
+	#.###.#.#...#.####.###.#....##.#.#...##.##...####
+	#.###.#.#.#.##.#.#.....####.#.###.#....##..###...
+	#.....#..####.....#.####.#..####.#....####.####.#
+	#######.##.###.##.#...#.##..#..#####..#....##.#.#
+`},
+}
+
+func TestEncodeQRReference(t *testing.T) {
+	for _, reference := range qrReferences {
+		code, err := encodeQR(reference.text)
+		if err != nil {
+			t.Fatalf("encodeQR(%q) failed: %v", reference.text, err)
+		}
+		rows := strings.Fields(reference.matrix)
+		if code.size != len(rows) {
+			t.Errorf("encodeQR(%q) is %d modules wide, want %d", reference.text, code.size, len(rows))
+			continue
+		}
+		for y, row := range rows {
+			for x, module := range row {
+				if code.modules[y][x] != (module == '#') {
+					t.Errorf("encodeQR(%q) differs from the reference at row %d, column %d", reference.text, y, x)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeQRInvalid(t *testing.T) {
+	if _, err := encodeQR("lowercase"); err != errQRCharacter {
+		t.Errorf("encodeQR with lowercase = %v, want errQRCharacter", err)
+	}
+	if _, err := encodeQR(strings.Repeat("A", 3392)); err != errQRTooLong {
+		t.Errorf("encodeQR with too much text = %v, want errQRTooLong", err)
+	}
+	if _, err := encodeQR(strings.Repeat("A", 3391)); err != nil {
+		t.Errorf("encodeQR with as much text as version 40 holds = %v", err)
+	}
+}
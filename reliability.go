@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+)
+
+// reliabilityHandler shows admins how well each scout's submissions agree
+// with the official scores and with other scouts, so they know whose data to
+// trust and who needs more training
+func reliabilityHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	user := db.GetUser(request)
+	if user == nil {
+		http.Redirect(writer, request, "/login", http.StatusFound)
+		return nil
+	}
+	if !user.Admin {
+		return data.ErrAccessDenied
+	}
+
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+	competition := request.FormValue("competition")
+	reliability, err := db.GetReliability(competition)
+	if err != nil {
+		return err
+	}
+
+	competitionOptions := []string{"", "All Competitions"}
+	for _, comp := range competitions {
+		competitionOptions = append(competitionOptions, comp.Key, comp.Name)
+	}
+
+	scouts := ""
+	for _, scout := range reliability.Scouts {
+		accuracy, bias := "-", "-"
+		if scout.Compared > 0 {
+			accuracy, bias = fmt.Sprintf("%.1f", scout.Error), fmt.Sprintf("%+.1f", scout.Bias)
+		}
+		scouts += fmt.Sprintf(`
+	<tr><td>%s</td><td>%d</td><td>%d</td><td>%s</td><td>%s</td><td>%d</td></tr>`,
+			html.EscapeString(scout.ScoutName), scout.Submissions, scout.Compared, accuracy, bias, scout.Flagged)
+	}
+
+	flagged := ""
+	for _, sub := range reliability.Flagged {
+		flagged += fmt.Sprintf(`
+	<tr><td><a href="/detailed?id=%d">%d</a></td><td>%s</td><td>%s%d</td><td>%s</td><td>%s</td><td>%s</td></tr>`,
+			sub.Id, sub.Id, html.EscapeString(sub.Competition), sub.Level.Abbreviation(), sub.Match,
+			genTeamLink(sub.Team), html.EscapeString(sub.ScoutName), html.EscapeString(sub.Reason))
+	}
+	if flagged == "" {
+		flagged = `
+	<tr><td colspan="6">Nothing stands out.</td></tr>`
+	}
+
+	return writeAll(writer,
+		genPageStart("Scout Reliability"),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genStylesheetElement("matches"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Scout Reliability</h1>
+<form class="match-filter" action="/reliability" method="get">
+	%s
+	<input type="submit" value="Show">
+</form>
+<p class="match-filter">
+	%d alliances had every robot scouted in a scored match.  Their scouted totals typically missed the official
+	score by %+.1f points, which is taken out before charging each scout.  Error is the average number of points
+	each submission was off by; bias is whether the scout tends to record too much (+) or too little (-).
+</p>
+<table class="team-table">
+	<tr><th>Scout</th><th>Submissions</th><th>Compared</th><th>Error</th><th>Bias</th><th>Flagged</th></tr>%s
+</table>
+<h2>Flagged Submissions</h2>
+<table class="team-table">
+	<tr><th>Submission</th><th>Competition</th><th>Match</th><th>Team</th><th>Scout</th><th>Reason</th></tr>%s
+</table>`, genSelect("competition", competition, competitionOptions...), reliability.Alliances, reliability.Bias,
+			scouts, flagged),
+		genPageEnd())
+}
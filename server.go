@@ -59,8 +59,10 @@ func cacheStaticPages() error {
 				contentType = "text/javascript"
 			case ".css":
 				contentType = "text/css"
-			case ".jpg", ".jpeg", ".ico":
+			case ".jpg", ".jpeg":
 				contentType = "image/jpeg"
+			case ".ico":
+				contentType = "image/x-icon"
 			default:
 				return errUnknownFileExtension
 			}
@@ -76,18 +78,34 @@ func cacheStaticPages() error {
 }
 
 func setupHandlers() {
-	http.Handle("/login", safeHandler(loginHandler))   // login as a user
-	http.Handle("/logout", safeHandler(logoutHandler)) // logout and redirect to main page
-	http.Handle("/signup", safeHandler(signupHandler)) // signup w/ admin authorization
+	http.Handle("/login", safeHandler(loginHandler))       // login as a user
+	http.Handle("/logout", safeHandler(logoutHandler))     // logout and redirect to main page
+	http.Handle("/signup", safeHandler(signupHandler))     // signup w/ admin authorization
+	http.Handle("/sessions", safeHandler(sessionsHandler)) // the devices a user is logged in on
+	http.Handle("/users", safeHandler(usersHandler))       // admins grant and revoke the roles of users
 
-	// http.Handle("/competitions", nil) // a list of all competitions and all the teams that attend them
-	// http.Handle("/teams", nil)        // a list of all teams w/ their track records
-	// http.Handle("/matches", nil)      // a list of all matches w/ general scorint info
-	// http.Handle("/all", nil)          // a list of all submissions w/ brief overviews
-	// http.Handle("/detailed", nil)     // a view of single submissions in full detail
-	// http.Handle("/analysis", nil)     // a view of robots ranked for certain characteristics
+	http.Handle("/competitions", safeHandler(competitionsHandler)) // a list of all competitions and all the teams that attend them
+	http.Handle("/teams", safeHandler(teamsHandler))               // a list of all teams w/ their track records
+	http.Handle("/teams/", safeHandler(teamsHandler))              // a single team's track record
+	http.Handle("/matches", safeHandler(matchesHandler))           // a list of all matches w/ general scorint info
+	http.Handle("/assignments", safeHandler(assignmentsHandler))   // which scout watches which robot in every match
+	http.Handle("/all", safeHandler(allHandler))                   // a list of all submissions w/ brief overviews
+	http.Handle("/detailed", safeHandler(detailedHandler))         // a view of single submissions in full detail
+	http.Handle("/analysis", safeHandler(analysisHandler))         // a view of robots ranked for certain characteristics
+	http.Handle("/reliability", safeHandler(reliabilityHandler))   // how well each scout agrees with official scores
+	http.Handle("/export", safeHandler(exportHandler))             // every submission, pit record and match result as CSV or JSON
+	http.Handle("/import", safeHandler(importHandler))             // load old submissions and schedules from CSV files
 
-	// http.Handle("/submit", nil) // submit a new entry into the data collection
+	http.Handle("/submit", safeHandler(submitHandler))      // submit a new entry into the data collection
+	http.Handle("/sync", safeHandler(syncHandler))          // submit entries queued while offline
+	http.Handle("/qr", safeHandler(qrHandler))              // pack an entry into a QR code
+	http.Handle("/qr/import", safeHandler(qrImportHandler)) // submit entries scanned from QR codes
+	http.Handle("/pit", safeHandler(pitHandler))            // record what a team's robot is like
+	http.Handle("/photos", safeHandler(photosHandler))      // upload a photo of a team's robot
+	http.Handle("/photos/", safeHandler(photosHandler))     // a single uploaded photo or its thumbnail
+
+	http.Handle("/picklists", safeHandler(picklistsHandler))  // pick lists for alliance selection
+	http.Handle("/picklists/", safeHandler(picklistsHandler)) // a single pick list and the teams already picked
 
 	http.Handle("/", safeHandler(indexHandler)) // the main page; also handles static pages for resource files
 }
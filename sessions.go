@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+	"strconv"
+	"strings"
+)
+
+// sessionTimeLayout is how the times of sessions are shown
+const sessionTimeLayout = "Jan 2 3:04 PM"
+
+// sessionsHandler lists the devices a user is logged in on and lets them log
+// any of them out, such as a tablet that was handed to someone else
+func sessionsHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	user := db.GetUser(request)
+	if user == nil {
+		if request.Method == "GET" {
+			http.Redirect(writer, request, "/login", http.StatusFound)
+			return nil
+		}
+		return data.ErrAccessDenied
+	}
+
+	if request.Method == "POST" {
+		if request.ParseForm() != nil {
+			return data.ErrMalformedRequest
+		}
+		if request.PostFormValue("action") == "revoke-others" {
+			if err := db.RevokeOtherSessions(user); err != nil {
+				return err
+			}
+		} else {
+			id, err := strconv.ParseInt(request.PostFormValue("id"), 10, 64)
+			if err != nil {
+				return data.ErrMalformedRequest
+			}
+			if err = db.RevokeSession(user, id); err != nil && err != data.ErrSessionNotFound {
+				return err
+			}
+			if id == user.SessionId {
+				// revoking this session is the same as logging out
+				db.Logout(writer, request)
+				http.Redirect(writer, request, "/login", http.StatusFound)
+				return nil
+			}
+		}
+		http.Redirect(writer, request, "/sessions", http.StatusFound)
+		return nil
+	} else if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	sessions, err := db.GetSessions(user)
+	if err != nil {
+		return err
+	}
+
+	rows := ""
+	for _, session := range sessions {
+		device := html.EscapeString(session.Device)
+		if device == "" {
+			device = "Unknown device"
+		}
+		if session.Current {
+			device += " (this device)"
+		}
+		rows += fmt.Sprintf(`
+	<tr>
+		<td>%s</td><td>%s</td><td>%s</td><td>%s</td>
+		<td><form action="/sessions" method="post"><input type="hidden" name="id" value="%d"><input type="submit" value="Log Out"></form></td>
+	</tr>`, device, session.Created.Local().Format(sessionTimeLayout), session.LastSeen.Local().Format(sessionTimeLayout),
+			session.Expires.Local().Format(sessionTimeLayout), session.Id)
+	}
+
+	return writeAll(writer,
+		genPageStart("Active Sessions"),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Active Sessions</h1>
+<p>
+	You're logged in as %s on these devices.  Log out of any you no longer use, especially shared tablets.
+</p>
+<table class="team-table">
+	<tr><th>Device</th><th>Logged In</th><th>Last Used</th><th>Expires</th><th></th></tr>%s
+</table>
+<form action="/sessions" method="post">
+	<input type="hidden" name="action" value="revoke-others">
+	<input type="submit" value="Log Out Everywhere Else">
+</form>`, html.EscapeString(user.Username), rows),
+		genPageEnd())
+}
+
+// requestDevice names the device a request to log in came from, which is what
+// the user called it or else a guess from its user agent
+func requestDevice(request *http.Request) string {
+	if device := strings.TrimSpace(request.PostFormValue("device")); device != "" {
+		return device
+	}
+	agent := request.UserAgent()
+	platforms := []struct{ marker, name string }{
+		{"iPad", "iPad"},
+		{"iPhone", "iPhone"},
+		{"Android", "Android"},
+		{"CrOS", "Chromebook"},
+		{"Windows", "Windows"},
+		{"Macintosh", "Mac"},
+		{"Linux", "Linux"},
+	}
+	browsers := []struct{ marker, name string }{
+		{"Edg/", "Edge"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	platform, browser := "", ""
+	for _, p := range platforms {
+		if strings.Contains(agent, p.marker) {
+			platform = p.name
+			break
+		}
+	}
+	for _, b := range browsers {
+		if strings.Contains(agent, b.marker) {
+			browser = b.name
+			break
+		}
+	}
+	switch {
+	case platform != "" && browser != "":
+		return browser + " on " + platform
+	case platform != "":
+		return platform
+	}
+	return browser
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"scout/data"
+	"strconv"
+	"strings"
+)
+
+const submissionsPerPage = 50
+
+// allHandler lists every submission a page at a time, optionally filtered by
+// competition, team, match and scout
+func allHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	season, err := data.GetSeason(year)
+	if err != nil {
+		return err
+	}
+
+	team, _ := strconv.ParseInt(request.FormValue("team"), 10, 16)
+	match, _ := strconv.ParseInt(request.FormValue("match"), 10, 16)
+	page, _ := strconv.Atoi(request.FormValue("page"))
+	if page < 1 {
+		page = 1
+	}
+	filter := data.SubmissionFilter{
+		Competition: request.FormValue("competition"),
+		Team:        int(team),
+		Match:       int(match),
+		Scout:       request.FormValue("scout"),
+		Offset:      (page - 1) * submissionsPerPage,
+		Limit:       submissionsPerPage,
+	}
+
+	count, err := db.CountSubmissions(filter)
+	if err != nil {
+		return err
+	}
+	subs, err := db.GetSubmissions(filter)
+	if err != nil {
+		return err
+	}
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+
+	rows := ""
+	for _, sub := range subs {
+		rows += fmt.Sprintf(`
+	<tr><td><a href="/detailed?id=%d">%d</a></td><td>%s</td><td>%s%d</td><td>%s</td><td class="alliance-%s">%s</td><td>%s</td><td>%s</td><td class="submission-summary">%s</td></tr>`,
+			sub.Id, sub.Id, html.EscapeString(sub.Competition), sub.Level.Abbreviation(), sub.Match,
+			genTeamLink(sub.Team), sub.Alliance, sub.Alliance, html.EscapeString(sub.ScoutName), sub.Time,
+			html.EscapeString(genSubmissionSummary(season, sub)))
+	}
+
+	competitionOptions := []string{"", "All Competitions"}
+	for _, comp := range competitions {
+		competitionOptions = append(competitionOptions, comp.Key, comp.Name)
+	}
+	teamValue, matchValue := "", ""
+	if filter.Team > 0 {
+		teamValue = strconv.Itoa(filter.Team)
+	}
+	if filter.Match > 0 {
+		matchValue = strconv.Itoa(filter.Match)
+	}
+
+	// keep the filter when moving between pages
+	query := url.Values{}
+	for _, name := range []string{"competition", "team", "match", "scout"} {
+		if value := request.FormValue(name); value != "" {
+			query.Set(name, value)
+		}
+	}
+	pager := ""
+	if page > 1 {
+		query.Set("page", strconv.Itoa(page-1))
+		pager += fmt.Sprintf(`<a href="/all?%s">Previous</a> `, html.EscapeString(query.Encode()))
+	}
+	pages := (count + submissionsPerPage - 1) / submissionsPerPage
+	pager += fmt.Sprintf(`<span>Page %d of %d</span>`, page, pages)
+	if page < pages {
+		query.Set("page", strconv.Itoa(page+1))
+		pager += fmt.Sprintf(` <a href="/all?%s">Next</a>`, html.EscapeString(query.Encode()))
+	}
+
+	return writeAll(writer,
+		genPageStart("All Submissions"),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genStylesheetElement("submissions"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>All Submissions</h1>
+<form class="submission-filter" action="all" method="get">
+	%s
+	%s
+	%s
+	%s
+	<input type="submit" value="Filter">
+</form>
+<div class="submission-pager">%d submissions. %s</div>
+<table class="team-table">
+	<tr><th>#</th><th>Competition</th><th>Match</th><th>Team</th><th>Alliance</th><th>Scout</th><th>Time</th><th>Summary</th></tr>%s
+</table>`,
+			genSelect("competition", filter.Competition, competitionOptions...),
+			genTextInput("team", teamValue, "Team Number", false),
+			genTextInput("match", matchValue, "Match Number", false),
+			genTextInput("scout", html.EscapeString(filter.Scout), "Scout Username", false),
+			count, pager, rows),
+		genPageEnd())
+}
+
+// detailedHandler shows a single submission in full, and lets its scout or an
+// admin edit or delete it
+func detailedHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	season, err := data.GetSeason(year)
+	if err != nil {
+		return err
+	}
+
+	if request.ParseForm() != nil {
+		return data.ErrMalformedRequest
+	}
+	id, err := strconv.ParseInt(request.FormValue("id"), 10, 64)
+	if err != nil {
+		return data.ErrNotFound
+	}
+	sub, err := db.GetSubmission(id)
+	if err != nil {
+		return err
+	}
+	user := db.GetUser(request)
+
+	message := ""
+	edited := *sub
+	if request.Method == "POST" {
+		if user == nil {
+			return data.ErrAccessDenied
+		}
+		switch request.FormValue("action") {
+		case "delete":
+			if err = db.DeleteSubmission(user, id); err != nil {
+				return err
+			}
+			http.Redirect(writer, request, "/all", http.StatusFound)
+			return nil
+		case "edit":
+			if message = readSubmissionForm(request, season, &edited); message != "" {
+				break
+			}
+			err = db.UpdateSubmission(user, &edited)
+			if err == nil {
+				http.Redirect(writer, request, fmt.Sprintf("/detailed?id=%d", id), http.StatusFound)
+				return nil
+			}
+			var ok bool
+			if message, ok = submissionErrorText(err); !ok {
+				return err
+			}
+		default:
+			return data.ErrMalformedRequest
+		}
+	} else if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	edits, err := db.GetSubmissionEdits(id)
+	if err != nil {
+		return err
+	}
+
+	values := ""
+	for _, field := range season.Fields {
+		values += fmt.Sprintf(`
+	<tr><th>%s</th><td>%s</td></tr>`, html.EscapeString(field.Label), html.EscapeString(sub.Values[field.Name]))
+	}
+
+	history := ""
+	for i, edit := range edits {
+		after := *sub
+		if i+1 < len(edits) {
+			after = edits[i+1].Previous
+		}
+		changes := submissionChanges(season, edit.Previous, after)
+		if len(changes) == 0 {
+			changes = append(changes, "no changes")
+		}
+		history += fmt.Sprintf(`
+	<li>Edited by %s at %s: %s</li>`, html.EscapeString(edit.EditorName), edit.Time,
+			html.EscapeString(strings.Join(changes, "; ")))
+	}
+	if history == "" {
+		history = `
+	<li>No edits</li>`
+	}
+
+	editor := ""
+	if sub.EditableBy(user) {
+		competitions, err := db.GetCompetitions()
+		if err != nil {
+			return err
+		}
+		editor = fmt.Sprintf(`
+<h2>Edit</h2>
+<span class="submit-message">%s</span>
+%s
+<form name="delete" action="detailed?id=%d&amp;action=delete" method="post" onsubmit="return confirm('Delete this submission?');">
+	<input type="submit" value="Delete Submission">
+</form>`, message, genSubmissionForm(fmt.Sprintf("detailed?id=%d&amp;action=edit", id), "Save Changes", season, competitions, edited), id)
+	}
+
+	return writeAll(writer,
+		genPageStart(fmt.Sprintf("Submission %d", id)),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genStylesheetElement("submit"),
+		genStylesheetElement("submissions"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Submission %d</h1>
+<div class="submission-origin">Submitted by %s at %s</div>
+<table class="team-table">
+	<tr><th>Competition</th><td>%s</td></tr>
+	<tr><th>Match</th><td>%s%d</td></tr>
+	<tr><th>Team</th><td>%s</td></tr>
+	<tr><th>Alliance</th><td class="alliance-%s">%s</td></tr>%s
+</table>
+<h2>History</h2>
+<ul class="submission-history">%s
+</ul>%s`, id, html.EscapeString(sub.ScoutName), sub.Time,
+			html.EscapeString(sub.Competition), sub.Level.Abbreviation(), sub.Match, genTeamLink(sub.Team),
+			sub.Alliance, sub.Alliance, values, history, editor),
+		genPageEnd())
+}
+
+// genSubmissionSummary briefly describes the numeric and enumerated values of
+// a submission, skipping the ones that are zero
+func genSubmissionSummary(season *data.Season, sub data.Submission) string {
+	parts := []string{}
+	for _, field := range season.Fields {
+		value := sub.Values[field.Name]
+		switch field.Kind {
+		case data.FieldCounter:
+			if value != "" && value != "0" {
+				parts = append(parts, field.Name+" "+value)
+			}
+		case data.FieldBoolean:
+			if value == "1" {
+				parts = append(parts, field.Name)
+			}
+		case data.FieldEnum:
+			parts = append(parts, field.Name+" "+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// submissionChanges describes everything that differs between two versions of
+// a submission
+func submissionChanges(season *data.Season, before, after data.Submission) []string {
+	changes := []string{}
+	describe := func(name, old, new string) {
+		if old != new {
+			changes = append(changes, fmt.Sprintf("%s %s → %s", name, old, new))
+		}
+	}
+	describe("competition", before.Competition, after.Competition)
+	describe("match", fmt.Sprintf("%s%d", before.Level.Abbreviation(), before.Match),
+		fmt.Sprintf("%s%d", after.Level.Abbreviation(), after.Match))
+	describe("team", strconv.Itoa(before.Team), strconv.Itoa(after.Team))
+	describe("alliance", before.Alliance.String(), after.Alliance.String())
+	return append(changes, valueChanges(season.Fields, before.Values, after.Values)...)
+}
+
+// valueChanges describes every field whose value differs between two sets of
+// values
+func valueChanges(fields []data.Field, before, after map[string]string) []string {
+	changes := []string{}
+	for _, field := range fields {
+		if old, new := before[field.Name], after[field.Name]; old != new {
+			changes = append(changes, fmt.Sprintf("%s %s → %s", field.Label, old, new))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+	"strconv"
+)
+
+func submitHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	season, err := data.GetSeason(year)
+	if err != nil {
+		return err
+	}
+
+	user := db.GetUser(request)
+	if user == nil {
+		if request.Method == "GET" {
+			http.Redirect(writer, request, "/login", http.StatusFound)
+			return nil
+		}
+		return data.ErrAccessDenied
+	}
+
+	if request.ParseForm() != nil {
+		return data.ErrMalformedRequest
+	}
+
+	competitions, err := db.GetCompetitions()
+	if err != nil {
+		return err
+	}
+
+	sub := data.Submission{ScoutId: user.Id, Values: map[string]string{}}
+	if request.Method == "POST" {
+		if message := readSubmissionForm(request, season, &sub); message != "" {
+			return deliverSubmit(writer, request, season, competitions, message, sub)
+		}
+
+		err = db.InsertSubmission(&sub)
+		if err == nil {
+			// start the next submission on the following match
+			message := fmt.Sprintf("Saved team %d in match %s%d", sub.Team, sub.Level.Abbreviation(), sub.Match)
+			next := data.Submission{Competition: sub.Competition, Level: sub.Level, Match: sub.Match + 1, Alliance: sub.Alliance}
+			return deliverSubmit(writer, request, season, competitions, message, next)
+		}
+		if message, ok := submissionErrorText(err); ok {
+			return deliverSubmit(writer, request, season, competitions, message, sub)
+		}
+		return err
+	} else if request.Method == "GET" {
+		// the form may be filled in ahead of time, such as for a scout's next assignment
+		match, _ := strconv.ParseInt(request.FormValue("match"), 10, 16)
+		team, _ := strconv.ParseInt(request.FormValue("team"), 10, 16)
+		sub.Competition = request.FormValue("competition")
+		sub.Level, _ = data.ParseMatchLevel(request.FormValue("level"))
+		sub.Alliance, _ = data.ParseAlliance(request.FormValue("alliance"))
+		sub.Match, sub.Team = int(match), int(team)
+		return deliverSubmit(writer, request, season, competitions, "", sub)
+	}
+	return data.ErrHTTPMethodUnsupported
+}
+
+// readSubmissionForm fills in the submission from a posted submission form.
+// Returns a message for the user if the form couldn't be understood.
+func readSubmissionForm(request *http.Request, season *data.Season, sub *data.Submission) string {
+	var err error
+	match, _ := strconv.ParseInt(request.PostFormValue("match"), 10, 16)
+	team, _ := strconv.ParseInt(request.PostFormValue("team-number"), 10, 16)
+	sub.Competition = request.PostFormValue("competition")
+	sub.Match = int(match)
+	sub.Team = int(team)
+	sub.Level, err = data.ParseMatchLevel(request.PostFormValue("level"))
+	if err != nil {
+		return "Pick whether the match was a qualification or a playoff"
+	}
+	sub.Alliance, err = data.ParseAlliance(request.PostFormValue("alliance"))
+	if err != nil {
+		return "Pick the alliance the robot was on"
+	}
+
+	sub.Values = readFieldValues(request, season.Fields)
+	return ""
+}
+
+// readFieldValues reads the posted value of every field
+func readFieldValues(request *http.Request, fields []data.Field) map[string]string {
+	values := map[string]string{}
+	for _, field := range fields {
+		value := request.PostFormValue(field.Name)
+		if field.Kind == data.FieldBoolean && value == "" {
+			value = "0" // unchecked boxes aren't sent at all
+		}
+		values[field.Name] = value
+	}
+	return values
+}
+
+// submissionErrorText explains why a submission couldn't be saved.  Returns
+// false if the error wasn't caused by the contents of the submission.
+func submissionErrorText(err error) (string, bool) {
+	switch err {
+	case data.ErrInvalidCompetition:
+		return "Pick the competition the match was part of", true
+	case data.ErrInvalidMatch:
+		return "The match number was not valid", true
+	case data.ErrInvalidTeam:
+		return "The team number was not valid", true
+	case data.ErrInvalidField:
+		return "One of the match values was missing or out of range", true
+	}
+	return "", false
+}
+
+func deliverSubmit(writer http.ResponseWriter, request *http.Request, season *data.Season, competitions []data.Competition, message string, sub data.Submission) error {
+	return writeAll(writer,
+		genPageStart("Submit"),
+		genStylesheetElement("main"),
+		genStylesheetElement("submit"),
+		genScriptElement("offline"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<span class="submit-message">%s</span>
+<span id="sync-status" class="submit-message"></span>`, message),
+		genSubmissionForm("submit", "Submit", season, competitions, sub),
+		`
+<button class="submit-message" form="submission-form" formaction="/qr" type="submit">Show as QR Code</button>`,
+		genPageEnd())
+}
+
+// genSubmissionForm creates a form for filling in every part of a
+// submission.  The form is posted to action.
+func genSubmissionForm(action, button string, season *data.Season, competitions []data.Competition, sub data.Submission) string {
+	fields := ""
+	for _, field := range season.Fields {
+		fields += fmt.Sprintf(`
+		<label class="submit-field">%s %s</label>`, field.Label, genFieldInput(field, sub.Values[field.Name]))
+	}
+
+	competitionOptions := make([]string, 0, 2*len(competitions))
+	for _, comp := range competitions {
+		competitionOptions = append(competitionOptions, comp.Key, comp.Name)
+	}
+
+	matchValue := ""
+	if sub.Match > 0 {
+		matchValue = strconv.Itoa(sub.Match)
+	}
+	return fmt.Sprintf(`
+<form id="submission-form" name="submit" action="%s" method="post" accept-charset="utf-8">
+	<div class="submit-match">
+		<label>Match</label>
+		%s
+		%s
+		<input name="match" value="%s" placeholder="Match Number" type="number" min="1" step="1" required>
+		%s
+		%s
+	</div>
+	<div class="submit-fields">%s
+	</div>
+	<input type="submit" value="%s">
+</form>`, action,
+		genSelect("competition", sub.Competition, competitionOptions...),
+		genSelect("level", sub.Level.String(), "qual", "Qualification", "playoff", "Playoff"),
+		matchValue,
+		genTeamNumberForm(sub.Team),
+		genSelect("alliance", sub.Alliance.String(), "red", "Red", "blue", "Blue"),
+		fields, button)
+}
+
+// genFieldInput creates the form input appropriate for a season field
+func genFieldInput(field data.Field, value string) string {
+	switch field.Kind {
+	case data.FieldBoolean:
+		return genCheckbox(field.Name, value == "1")
+	case data.FieldCounter:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			n = field.Min
+		}
+		return genNumberInput(field.Name, n, field.Min, field.Max)
+	case data.FieldEnum:
+		options := make([]string, 0, 2*len(field.Options))
+		for _, option := range field.Options {
+			options = append(options, option, option)
+		}
+		return genSelect(field.Name, value, options...)
+	case data.FieldText:
+		return genTextInput(field.Name, html.EscapeString(value), field.Label, field.Min > 0)
+	}
+	return ""
+}
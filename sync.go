@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"scout/data"
+)
+
+// maxSyncSize is the largest batch of submissions, in bytes, that may be
+// synced at once.  js/offline.js splits its queue into batches smaller than
+// this, so the two must be kept the same.
+const maxSyncSize = 1 << 20
+
+// syncRequest is a batch of submissions made while offline
+type syncRequest struct {
+	Submissions []syncSubmission `json:"submissions"`
+}
+
+// syncSubmission is a submission as it's queued by js/offline.js
+type syncSubmission struct {
+	ClientId    string            `json:"clientId"`
+	Competition string            `json:"competition"`
+	Level       string            `json:"level"`
+	Match       int               `json:"match"`
+	Team        int               `json:"team"`
+	Alliance    string            `json:"alliance"`
+	Values      map[string]string `json:"values"`
+}
+
+// syncResponse reports what happened to each submission of a batch, in the
+// order they were sent
+type syncResponse struct {
+	Results []syncResult `json:"results"`
+}
+
+type syncResult struct {
+	ClientId string          `json:"clientId"`
+	Status   data.SyncStatus `json:"status"`
+	Id       int64           `json:"id,omitempty"`
+	Message  string          `json:"message,omitempty"`
+}
+
+// syncHandler stores batches of submissions queued by scouts while they were
+// offline.  Each submission carries an id made up by the client, so a batch
+// may be resent any number of times without storing anything twice.
+func syncHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	if request.Method != "POST" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	user := db.GetUser(request)
+	if user == nil {
+		return data.ErrAccessDenied
+	}
+
+	var batch syncRequest
+	err := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxSyncSize)).Decode(&batch)
+	if err != nil {
+		return data.ErrMalformedRequest
+	}
+
+	response := syncResponse{Results: make([]syncResult, 0, len(batch.Submissions))}
+	for _, item := range batch.Submissions {
+		result := syncResult{ClientId: item.ClientId, Status: data.SyncInvalid}
+		sub := data.Submission{
+			Competition: item.Competition,
+			Match:       item.Match,
+			Team:        item.Team,
+			ClientId:    item.ClientId,
+			Values:      item.Values,
+		}
+		if sub.Level, err = data.ParseMatchLevel(item.Level); err != nil {
+			result.Message = "Pick whether the match was a qualification or a playoff"
+		} else if sub.Alliance, err = data.ParseAlliance(item.Alliance); err != nil {
+			result.Message = "Pick the alliance the robot was on"
+		} else {
+			synced, err := db.SyncSubmission(user, &sub)
+			if err != nil {
+				return err
+			}
+			result.Status, result.Id = synced.Status, synced.Id
+			switch synced.Status {
+			case data.SyncConflict:
+				result.Message = "A different submission was already saved with this id"
+			case data.SyncInvalid:
+				result.Message = syncErrorText(synced.Err)
+			}
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(writer).Encode(response)
+}
+
+// syncErrorText explains why a synced submission was rejected
+func syncErrorText(err error) string {
+	if message, ok := submissionErrorText(err); ok {
+		return message
+	}
+	if err == data.ErrInvalidClientId {
+		return "The submission's id was missing or malformed"
+	}
+	return "The submission couldn't be saved"
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// teamsHandler serves both the index of all teams at /teams and the profile
+// of a single team at /teams/{number}
+func teamsHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	season, err := data.GetSeason(year)
+	if err != nil {
+		return err
+	}
+
+	path := strings.Trim(strings.TrimPrefix(request.URL.Path, "/teams"), "/")
+	if path == "" {
+		return deliverTeamIndex(db, season, writer, request)
+	}
+
+	team, err := strconv.ParseInt(path, 10, 16)
+	if err != nil || team <= 0 {
+		return data.ErrNotFound
+	}
+	return deliverTeam(db, season, int(team), writer, request)
+}
+
+func deliverTeamIndex(db data.DB, season *data.Season, writer http.ResponseWriter, request *http.Request) error {
+	teams, err := db.GetTeams()
+	if err != nil {
+		return err
+	}
+	analysis, err := db.Analyze("")
+	if err != nil {
+		return err
+	}
+
+	// include the teams that haven't been scouted yet
+	summaries := append([]data.TeamSummary{}, analysis.Teams...)
+	scouted := map[int]bool{}
+	for _, summary := range summaries {
+		scouted[summary.Number] = true
+	}
+	for _, team := range teams {
+		if !scouted[team] {
+			summaries = append(summaries, data.SummarizeTeam(season, team, nil))
+		}
+	}
+
+	sortBy := request.FormValue("sort")
+	sort.SliceStable(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		switch {
+		case sortBy == "submissions":
+			return a.Submissions > b.Submissions
+		case season.Field(sortBy) != nil:
+			return a.Stats[sortBy].Mean > b.Stats[sortBy].Mean
+		}
+		return a.Number < b.Number
+	})
+
+	fields := season.NumericFields()
+	header := `<th><a href="/teams?sort=number">Team</a></th><th><a href="/teams?sort=submissions">Submissions</a></th>`
+	for _, field := range fields {
+		header += fmt.Sprintf(`<th><a href="/teams?sort=%s">%s</a></th>`, field.Name, html.EscapeString(field.Label))
+	}
+
+	rows := ""
+	for _, summary := range summaries {
+		rows += fmt.Sprintf(`
+	<tr><td>%s</td><td>%d</td>`, genTeamLink(summary.Number), summary.Submissions)
+		for _, field := range fields {
+			rows += fmt.Sprintf(`<td>%s</td>`, formatStat(summary.Stats[field.Name], summary.Stats[field.Name].Mean))
+		}
+		rows += `</tr>`
+	}
+
+	return writeAll(writer,
+		genPageStart("Teams"),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Teams</h1>
+<table class="team-table">
+	<tr>%s</tr>%s
+</table>`, header, rows),
+		genPageEnd())
+}
+
+func deliverTeam(db data.DB, season *data.Season, team int, writer http.ResponseWriter, request *http.Request) error {
+	subs, err := db.GetSubmissions(data.SubmissionFilter{Team: team})
+	if err != nil {
+		return err
+	}
+	competitions, err := db.GetTeamCompetitions(team)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 && len(competitions) == 0 {
+		return data.ErrNotFound
+	}
+	matches, err := db.GetMatches("")
+	if err != nil {
+		return err
+	}
+	summary := data.SummarizeTeam(season, team, subs)
+
+	records, err := db.GetTeamPitRecords(team)
+	if err != nil {
+		return err
+	}
+	pit := ""
+	for _, record := range records {
+		revisions, err := db.GetPitRevisions(record)
+		if err != nil {
+			return err
+		}
+		pit += genPitRecord(season, record, revisions)
+	}
+
+	photos, err := db.GetTeamPhotos(team)
+	if err != nil {
+		return err
+	}
+	gallery := ""
+	for _, photo := range photos {
+		gallery += fmt.Sprintf(`
+	<a href="/photos/%d"><img src="/photos/%d/thumb" alt="Taken by %s at %s" title="Taken by %s at %s"></a>`,
+			photo.Id, photo.Id, html.EscapeString(photo.UploaderName), photo.Time,
+			html.EscapeString(photo.UploaderName), photo.Time)
+	}
+	competitionOptions := make([]string, 0, 2*len(competitions))
+	for _, competition := range competitions {
+		competitionOptions = append(competitionOptions, competition, competition)
+	}
+	latest := ""
+	if len(competitions) > 0 {
+		latest = competitions[len(competitions)-1]
+	}
+
+	ratingRows := ""
+	for _, competition := range competitions {
+		ratings, err := db.GetPowerRatings(competition)
+		if err != nil {
+			return err
+		}
+		for _, rating := range ratings {
+			if rating.Team == team {
+				ratingRows += fmt.Sprintf(`
+	<tr><td>%s</td><td>%.2f</td><td>%.2f</td><td>%.2f</td></tr>`,
+					html.EscapeString(competition), rating.OPR, rating.DPR, rating.CCWM)
+			}
+		}
+	}
+
+	statRows := ""
+	for _, field := range season.NumericFields() {
+		stats := summary.Stats[field.Name]
+		statRows += fmt.Sprintf(`
+	<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>`,
+			html.EscapeString(field.Label),
+			formatStat(stats, stats.Mean), formatStat(stats, stats.Median), formatStat(stats, stats.StdDev),
+			formatStat(stats, stats.Min), formatStat(stats, stats.Max), formatTrend(stats))
+	}
+
+	fieldHeaders := ""
+	for _, field := range season.Fields {
+		fieldHeaders += fmt.Sprintf(`<th>%s</th>`, html.EscapeString(field.Label))
+	}
+	subRows := ""
+	for _, sub := range subs {
+		subRows += fmt.Sprintf(`
+	<tr><td>%s</td><td>%s%d</td><td class="alliance-%s">%s</td><td>%s</td><td>%s</td>`,
+			html.EscapeString(sub.Competition), sub.Level.Abbreviation(), sub.Match, sub.Alliance, sub.Alliance,
+			html.EscapeString(sub.ScoutName), sub.Time)
+		for _, field := range season.Fields {
+			subRows += fmt.Sprintf(`<td>%s</td>`, html.EscapeString(sub.Values[field.Name]))
+		}
+		subRows += `</tr>`
+	}
+
+	return writeAll(writer,
+		genPageStart(fmt.Sprintf("Team %d", team)),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Team %d</h1>
+<div class="team-competitions">Competitions: %s</div>
+<div class="team-record">Record: %s</div>
+<h2>Power Ratings</h2>
+<table class="team-table">
+	<tr><th>Competition</th><th>OPR</th><th>DPR</th><th>CCWM</th></tr>%s
+</table>
+<h2>Pit Scouting</h2>%s
+<h2>Photos</h2>
+<div class="team-photos">%s
+</div>
+<form class="team-photo-upload" action="/photos" method="post" enctype="multipart/form-data">
+	<input name="team-number" value="%d" type="hidden">
+	%s
+	<input name="photo" type="file" accept="image/jpeg,image/png,image/gif" required>
+	<input type="submit" value="Upload Photo">
+</form>
+<h2>Performance over %d submissions</h2>
+<table class="team-table">
+	<tr><th>Field</th><th>Average</th><th>Median</th><th>Std Dev</th><th>Min</th><th>Max</th><th>Trend</th></tr>%s
+</table>
+<h2>Submissions</h2>
+<table class="team-table">
+	<tr><th>Competition</th><th>Match</th><th>Alliance</th><th>Scout</th><th>Time</th>%s</tr>%s
+</table>`, team, html.EscapeString(strings.Join(competitions, ", ")), data.RecordOf(team, matches), ratingRows, pit,
+			gallery, team, genSelect("competition", latest, competitionOptions...), len(subs),
+			statRows, fieldHeaders, subRows),
+		genPageEnd())
+}
+
+// formatStat formats a single statistic, leaving it blank if nothing was
+// recorded for the field
+func formatStat(stats data.FieldStats, value float64) string {
+	if stats.Count == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+// formatTrend formats the change per submission of a field
+func formatTrend(stats data.FieldStats) string {
+	if stats.Count < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%+.2f per match", stats.Trend)
+}
+
+func genTeamLink(team int) string {
+	return fmt.Sprintf(`<a href="/teams/%d">%d</a>`, team, team)
+}
+
+// genPitRecord shows a team's pit record at one competition along with how it
+// changed over time
+func genPitRecord(season *data.Season, record data.PitRecord, revisions []data.PitRecord) string {
+	values := ""
+	for _, field := range season.PitFields {
+		values += fmt.Sprintf(`
+	<tr><th>%s</th><td>%s</td></tr>`, html.EscapeString(field.Label), html.EscapeString(record.Values[field.Name]))
+	}
+
+	history := ""
+	for i, revision := range revisions {
+		after := record
+		if i+1 < len(revisions) {
+			after = revisions[i+1]
+		}
+		history += fmt.Sprintf(`
+	<li>Recorded by %s at %s, then changed: %s</li>`, html.EscapeString(revision.ScoutName), revision.Time,
+			html.EscapeString(strings.Join(valueChanges(season.PitFields, revision.Values, after.Values), "; ")))
+	}
+
+	return fmt.Sprintf(`
+<h3>%s <a href="/pit?competition=%s&amp;team-number=%d">edit</a></h3>
+<div class="team-pit-origin">Recorded by %s at %s</div>
+<table class="team-table">%s
+</table>
+<ul class="team-pit-history">%s
+</ul>`, html.EscapeString(record.Competition), html.EscapeString(record.Competition), record.Team,
+		html.EscapeString(record.ScoutName), record.Time, values, history)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"scout/data"
+	"strconv"
+)
+
+// usersHandler lists every user for admins, who can record the team each
+// user scouts for and make users strategists so they can build pick lists,
+// assign scouts and import QR codes without being admins themselves
+func usersHandler(year int, db data.DB, writer http.ResponseWriter, request *http.Request) error {
+	user := db.GetUser(request)
+	if user == nil && request.Method == "GET" {
+		http.Redirect(writer, request, "/login", http.StatusFound)
+		return nil
+	}
+	if user == nil || !user.Admin {
+		return data.ErrAccessDenied
+	}
+
+	if request.Method == "POST" {
+		if request.ParseForm() != nil {
+			return data.ErrMalformedRequest
+		}
+		id, err := strconv.ParseInt(request.PostFormValue("id"), 10, 64)
+		if err != nil {
+			return data.ErrMalformedRequest
+		}
+		if request.PostFormValue("action") == "team" {
+			team, err := strconv.Atoi(request.PostFormValue("team-number"))
+			if request.PostFormValue("team-number") == "" {
+				team, err = 0, nil
+			}
+			if err != nil {
+				return data.ErrMalformedRequest
+			}
+			err = db.SetUserTeam(user, id, team)
+		} else {
+			err = db.SetStrategist(user, id, request.PostFormValue("strategist") == "1")
+		}
+		if err != nil {
+			return err
+		}
+		http.Redirect(writer, request, "/users", http.StatusFound)
+		return nil
+	} else if request.Method != "GET" {
+		return data.ErrHTTPMethodUnsupported
+	}
+
+	users, err := db.GetUsers()
+	if err != nil {
+		return err
+	}
+
+	rows := ""
+	for _, u := range users {
+		role, action, value := "Scout", "Make Strategist", "1"
+		if u.Strategist {
+			role, action, value = "Strategist", "Remove Strategist", "0"
+		}
+		if u.Admin {
+			role = "Admin"
+		}
+		rows += fmt.Sprintf(`
+	<tr>
+		<td>%s</td><td>%s</td>
+		<td><form action="/users" method="post"><input type="hidden" name="id" value="%d"><input type="hidden" name="action" value="team">%s<input type="submit" value="Save"></form></td>
+		<td>%s</td>
+		<td><form action="/users" method="post"><input type="hidden" name="id" value="%d"><input type="hidden" name="strategist" value="%s"><input type="submit" value="%s"></form></td>
+	</tr>`, html.EscapeString(u.Username), html.EscapeString(u.RealName), u.Id, genTeamNumberForm(u.Team), role,
+			u.Id, value, action)
+	}
+
+	return writeAll(writer,
+		genPageStart("Users"),
+		genStylesheetElement("main"),
+		genStylesheetElement("teams"),
+		genTopBar(request),
+		fmt.Sprintf(`
+<h1>Users</h1>
+<p>
+	Strategists build pick lists, assign scouts to matches and import submissions from QR codes.  Admins can always do all of these.
+	Scouts are only kept from watching their own team's robot once their team is saved here.
+</p>
+<table class="team-table">
+	<tr><th>Username</th><th>Name</th><th>Team</th><th>Role</th><th></th></tr>%s
+</table>`, rows),
+		genPageEnd())
+}